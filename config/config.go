@@ -18,6 +18,43 @@ type Config struct {
 	LLMApiKey string `env:"LLM_API_KEY" envDefault:""`
 	LLMProvider string `env:"LLM_PROVIDER" envDefault:"openai"`
 	LLMUrl string `env:"LLM_URL" envDefault:"https://api.openai.com/v1/chat/completions"`
+	LLMStatementTimeoutMs int `env:"LLM_STATEMENT_TIMEOUT_MS" envDefault:"5000"`
+	LLMMaxRows int `env:"LLM_MAX_ROWS" envDefault:"500"`
+	LLMSchemaFile string `env:"LLM_SCHEMA_FILE" envDefault:""`
+	LLMModel string `env:"LLM_MODEL" envDefault:""`
+	LLMTemperature float64 `env:"LLM_TEMPERATURE" envDefault:"0.1"`
+	LLMMaxTokens int `env:"LLM_MAX_TOKENS" envDefault:"1000"`
+	LLMTimeoutSeconds int `env:"LLM_TIMEOUT_SECONDS" envDefault:"30"`
+	LLMConversationHistory int `env:"LLM_CONVERSATION_HISTORY" envDefault:"5"`
+	LLMStrategicMode bool `env:"LLM_STRATEGIC_MODE" envDefault:"true"`
+	LLMDailyQueryQuota int `env:"LLM_DAILY_QUERY_QUOTA" envDefault:"0"`
+	LLMDailyTokenQuota int `env:"LLM_DAILY_TOKEN_QUOTA" envDefault:"0"`
+	LLMSQLMaxAttempts int `env:"LLM_SQL_MAX_ATTEMPTS" envDefault:"3"`
+	LLMCacheEnabled bool `env:"LLM_CACHE_ENABLED" envDefault:"true"`
+	LLMCacheAnswerTTLSeconds int `env:"LLM_CACHE_ANSWER_TTL_SECONDS" envDefault:"0"`
+	SMTPHost string `env:"SMTP_HOST" envDefault:""`
+	SMTPPort string `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUser string `env:"SMTP_USER" envDefault:""`
+	SMTPPass string `env:"SMTP_PASS" envDefault:""`
+	MailFrom string `env:"MAIL_FROM" envDefault:"no-reply@orkestra.local"`
+	AppURL string `env:"APP_URL" envDefault:"http://localhost:5173"`
+	EmailVerificationTTLHours int `env:"EMAIL_VERIFICATION_TTL_HOURS" envDefault:"48"`
+	RequireVerifiedEmail bool `env:"REQUIRE_VERIFIED_EMAIL" envDefault:"false"`
+	PasswordResetTTLMinutes int `env:"PASSWORD_RESET_TTL_MINUTES" envDefault:"60"`
+	LoginMaxAttempts int `env:"LOGIN_MAX_ATTEMPTS" envDefault:"5"`
+	LoginMaxAttemptsPerIP int `env:"LOGIN_MAX_ATTEMPTS_PER_IP" envDefault:"20"`
+	LoginAttemptWindowSeconds int `env:"LOGIN_ATTEMPT_WINDOW_SECONDS" envDefault:"900"`
+	LoginLockoutSeconds int `env:"LOGIN_LOCKOUT_SECONDS" envDefault:"60"`
+	LoginMaxLockoutSeconds int `env:"LOGIN_MAX_LOCKOUT_SECONDS" envDefault:"3600"`
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:"," envDefault:""`
+	OpenRegistration bool `env:"OPEN_REGISTRATION" envDefault:"false"`
+	RegistrationProfileID string `env:"REGISTRATION_PROFILE_ID" envDefault:""`
+	AdminProfileID string `env:"ADMIN_PROFILE_ID" envDefault:""`
+	InvitationTTLHours int `env:"INVITATION_TTL_HOURS" envDefault:"168"`
+	APIURL string `env:"API_URL" envDefault:"http://localhost:8080"`
+	CalendarFeedPastDays int `env:"CALENDAR_FEED_PAST_DAYS" envDefault:"90"`
+	CalendarFeedFutureDays int `env:"CALENDAR_FEED_FUTURE_DAYS" envDefault:"365"`
+	MinutesTemplateDir string `env:"MINUTES_TEMPLATE_DIR" envDefault:""`
 }
 
 func LoadConfig() (*Config, error) {
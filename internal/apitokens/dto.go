@@ -0,0 +1,16 @@
+package apitokens
+
+import "time"
+
+// CreateAPITokenRequest crea un token. Sense expires_at el token no caduca.
+type CreateAPITokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIToken porta el token en clar. Només es retorna en crear-lo.
+type CreatedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,10 @@
+package apitokens
+
+import "errors"
+
+var (
+	ErrInvalidID     = errors.New("invalid API token ID")
+	ErrTokenNotFound = errors.New("API token not found")
+	ErrUnknownScope  = errors.New("unknown scope")
+	ErrInvalidExpiry = errors.New("expires_at must be in the future")
+)
@@ -0,0 +1,75 @@
+package apitokens
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenHandler struct {
+	service APITokenService
+}
+
+func NewAPITokenHandler(service APITokenService) *APITokenHandler {
+	return &APITokenHandler{
+		service: service,
+	}
+}
+
+func (h *APITokenHandler) GetMyTokens(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	tokens, err := h.service.FindByUserID(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *APITokenHandler) CreateMyToken(c *gin.Context) {
+	var request CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	token, err := h.service.Create(c.Request.Context(), userID.(string), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *APITokenHandler) DeleteMyToken(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	if err := h.service.Revoke(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrUnknownScope), errors.Is(err, ErrInvalidExpiry):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrTokenNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
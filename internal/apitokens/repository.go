@@ -0,0 +1,84 @@
+package apitokens
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type APITokenRepository interface {
+	Create(ctx context.Context, token APIToken) (APIToken, error)
+	FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]APIToken, error)
+	Revoke(ctx context.Context, id, userID uuid.UUID) error
+}
+
+type apiTokenRepository struct {
+	db *sql.DB
+}
+
+func NewAPITokenRepository(db *sql.DB) APITokenRepository {
+	return &apiTokenRepository{db: db}
+}
+
+func (r *apiTokenRepository) Create(ctx context.Context, token APIToken) (APIToken, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO api_tokens (id, user_id, name, token_hash, prefix, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), $7)
+		RETURNING created_at`,
+		token.ID, token.UserID, token.Name, token.TokenHash, token.Prefix, pq.Array(token.Scopes), token.ExpiresAt,
+	).Scan(&token.CreatedAt)
+	if err != nil {
+		return APIToken{}, err
+	}
+	return token, nil
+}
+
+func (r *apiTokenRepository) FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]APIToken, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, name, prefix, scopes, created_at, expires_at, last_used_at
+		FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		var token APIToken
+		if err := rows.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, pq.Array(&token.Scopes), &token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke revoca el token id només si és de l'usuari userID.
+func (r *apiTokenRepository) Revoke(ctx context.Context, id, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE api_tokens
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package apitokens
+
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registra la gestió dels tokens de l'usuari que fa la petició.
+// No cal cap permís, però sí una sessió: un token no en pot crear d'altres.
+func RegisterRoutes(router *gin.RouterGroup, handler *APITokenHandler) {
+	tokens := router.Group("/me/api-tokens", middleware.RejectAPITokens)
+	{
+		tokens.GET("", handler.GetMyTokens)
+		tokens.POST("", handler.CreateMyToken)
+		tokens.DELETE("/:id", handler.DeleteMyToken)
+	}
+}
@@ -0,0 +1,108 @@
+package apitokens
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"orkestra-api/internal/usertokens"
+	"orkestra-api/middleware"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScopeCatalog coneix els permisos que es poden donar a un token (middleware.Authorizer).
+type ScopeCatalog interface {
+	IsDeclared(permission string) bool
+}
+
+type APITokenService interface {
+	Create(ctx context.Context, userID string, request CreateAPITokenRequest) (CreatedAPIToken, error)
+	FindByUserID(ctx context.Context, userID string) ([]APIToken, error)
+	Revoke(ctx context.Context, userID, id string) error
+}
+
+// prefixLength són els caràcters del token que es desen en clar per identificar-lo.
+const prefixLength = 12
+
+type apiTokenService struct {
+	repo    APITokenRepository
+	catalog ScopeCatalog
+}
+
+func NewAPITokenService(repo APITokenRepository, catalog ScopeCatalog) APITokenService {
+	return &apiTokenService{repo: repo, catalog: catalog}
+}
+
+// Create genera un token ork_... i en retorna el valor en clar, que no es pot tornar a consultar.
+// Els scopes només limiten: el token mai té més permisos que el perfil de l'usuari.
+func (s *apiTokenService) Create(ctx context.Context, userID string, request CreateAPITokenRequest) (CreatedAPIToken, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return CreatedAPIToken{}, ErrInvalidID
+	}
+	scopes := make([]string, 0, len(request.Scopes))
+	seen := make(map[string]struct{}, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !s.catalog.IsDeclared(scope) {
+			return CreatedAPIToken{}, ErrUnknownScope
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		scopes = append(scopes, scope)
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return CreatedAPIToken{}, ErrInvalidExpiry
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return CreatedAPIToken{}, err
+	}
+	token := middleware.APITokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	created, err := s.repo.Create(ctx, APIToken{
+		ID:        uuid.New(),
+		UserID:    userUUID,
+		Name:      strings.TrimSpace(request.Name),
+		TokenHash: usertokens.HashToken(token),
+		Prefix:    token[:prefixLength],
+		Scopes:    scopes,
+		ExpiresAt: request.ExpiresAt,
+	})
+	if err != nil {
+		return CreatedAPIToken{}, err
+	}
+	return CreatedAPIToken{APIToken: created, Token: token}, nil
+}
+
+func (s *apiTokenService) FindByUserID(ctx context.Context, userID string) ([]APIToken, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return s.repo.FindActiveByUserID(ctx, userUUID)
+}
+
+// Revoke revoca un token de l'usuari. Els tokens d'altres usuaris es tracten com si no existissin.
+func (s *apiTokenService) Revoke(ctx context.Context, userID, id string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	tokenID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	err = s.repo.Revoke(ctx, tokenID, userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTokenNotFound
+	}
+	return err
+}
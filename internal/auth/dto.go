@@ -1,16 +1,34 @@
 package auth
 
 import (
+	"orkestra-api/internal/twofactor"
 	"orkestra-api/internal/users"
 )
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
+// TwoFactorLoginRequest és el segon pas del login: el token del primer pas i el codi
+// TOTP o de recuperació.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	UserAgent      string `json:"-"`
+	IP             string `json:"-"`
+}
+
+// LoginResponse porta el JWT o, si l'usuari té segon factor, el token per fer el segon
+// pas a /auth/login/2fa. Enrolment només hi és quan el perfil exigeix segon factor i
+// l'usuari encara no l'ha configurat.
 type LoginResponse struct {
 	Token  string `json:"token"`
 	Expire string `json:"expire"`
-	User   users.User   `json:"user"`	
+	User   *users.UserResponse `json:"user,omitempty"`	
+	TwoFactorRequired bool `json:"two_factor_required,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+	Enrolment *twofactor.Enrolment `json:"enrolment,omitempty"`
 }
\ No newline at end of file
@@ -1,9 +1,29 @@
 package auth
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
     ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserNotFound      = errors.New("user not found")
 	ErrInactiveUser      = errors.New("inactive user")
-)
\ No newline at end of file
+	ErrEmailNotVerified  = errors.New("email not verified")
+	ErrInvalidChallenge  = errors.New("invalid or expired two-factor challenge")
+	ErrTooManyAttempts   = errors.New("too many failed login attempts")
+	ErrInvalidID         = errors.New("invalid user ID")
+)
+
+// LockedError indica fins quan està bloquejat el login.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
\ No newline at end of file
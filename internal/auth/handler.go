@@ -1,8 +1,13 @@
 package auth
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"orkestra-api/internal/sessions"
+	"orkestra-api/internal/twofactor"
 	"orkestra-api/internal/users"
+	"strconv"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
@@ -29,13 +34,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
         return
     }
     
-    token,user, expire, err := h.authService.Login(c.Request.Context(), loginRequest)
+    loginRequest.UserAgent = c.Request.UserAgent()
+    loginRequest.IP = c.ClientIP()
+    response, err := h.authService.Login(c.Request.Context(), loginRequest)
     if err != nil {
+        var locked *LockedError
         var statusCode int
-        switch err {
-        case ErrInvalidCredentials:
+        switch {
+        case errors.As(err, &locked):
+            setRetryAfter(c, locked)
+            statusCode = http.StatusTooManyRequests
+        case errors.Is(err, ErrInvalidCredentials):
             statusCode = http.StatusUnauthorized
-        case users.ErrInactiveUser:
+        case errors.Is(err, ErrEmailNotVerified):
             statusCode = http.StatusForbidden
         default:
             statusCode = http.StatusInternalServerError
@@ -45,14 +56,82 @@ func (h *AuthHandler) Login(c *gin.Context) {
         return
     }
     
-    c.JSON(http.StatusOK, LoginResponse{
-        Token:  token,
-        Expire: expire.Format(time.RFC3339),
-        User:   user,
-    })
+    c.JSON(http.StatusOK, response)
 }
 
-// RefreshToken utilitza el middleware JWT per refrescar el token
+// LoginTwoFactor fa el segon pas del login amb el token de repte i el codi
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+    var request TwoFactorLoginRequest
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    request.UserAgent = c.Request.UserAgent()
+    request.IP = c.ClientIP()
+    response, err := h.authService.LoginTwoFactor(c.Request.Context(), request)
+    if err != nil {
+        var locked *LockedError
+        statusCode := http.StatusInternalServerError
+        switch {
+        case errors.As(err, &locked):
+            setRetryAfter(c, locked)
+            statusCode = http.StatusTooManyRequests
+        case errors.Is(err, ErrInvalidChallenge), errors.Is(err, twofactor.ErrInvalidCode), errors.Is(err, twofactor.ErrNotEnrolled):
+            statusCode = http.StatusUnauthorized
+        case errors.Is(err, users.ErrInactiveUser):
+            statusCode = http.StatusForbidden
+        }
+        c.JSON(statusCode, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+// setRetryAfter indica al client quants segons falten perquè s'acabi el bloqueig
+func setRetryAfter(c *gin.Context, locked *LockedError) {
+    retryAfter := int(math.Ceil(time.Until(locked.Until).Seconds()))
+    if retryAfter < 1 {
+        retryAfter = 1
+    }
+    c.Header("Retry-After", strconv.Itoa(retryAfter))
+}
+
+// RefreshToken utilitza el middleware JWT per refrescar el token, però només si la
+// sessió no s'ha revocat
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
+    claims, err := h.jwtMiddleware.CheckIfTokenExpire(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+        return
+    }
+    sessionID, _ := claims["jti"].(string)
+    if err := h.authService.RefreshSession(c.Request.Context(), sessionID); err != nil {
+        status := http.StatusInternalServerError
+        if errors.Is(err, sessions.ErrSessionExpired) {
+            status = http.StatusUnauthorized
+        }
+        c.JSON(status, gin.H{"code": status, "message": err.Error()})
+        return
+    }
     h.jwtMiddleware.RefreshHandler(c)
 }
+
+// Unlock treu el bloqueig per intents fallits del nom d'usuari d'un usuari i de les seves IPs
+func (h *AuthHandler) Unlock(c *gin.Context) {
+    err := h.authService.Unlock(c.Request.Context(), c.Param("id"))
+    if err != nil {
+        statusCode := http.StatusInternalServerError
+        switch {
+        case errors.Is(err, ErrInvalidID):
+            statusCode = http.StatusBadRequest
+        case errors.Is(err, users.ErrUserNotFound), errors.Is(err, users.ErrInactiveUser):
+            statusCode = http.StatusNotFound
+        }
+        c.JSON(statusCode, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ThrottleRepository interface {
+	LockedUntil(ctx context.Context, keys ...string) (*time.Time, error)
+	RecordFailure(ctx context.Context, key, ip string, window time.Duration) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	ResetWithIPs(ctx context.Context, key string) error
+}
+
+type throttleRepository struct {
+	db *sql.DB
+}
+
+func NewThrottleRepository(db *sql.DB) ThrottleRepository {
+	return &throttleRepository{db: db}
+}
+
+// LockedUntil retorna el bloqueig vigent més llarg entre les claus, o nil si no n'hi ha cap.
+func (r *throttleRepository) LockedUntil(ctx context.Context, keys ...string) (*time.Time, error) {
+	var lockedUntil *time.Time
+	for _, key := range keys {
+		var until sql.NullTime
+		err := r.db.QueryRowContext(ctx, `
+			SELECT locked_until
+			FROM login_throttles
+			WHERE key = $1 AND locked_until > NOW()`,
+			key,
+		).Scan(&until)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if lockedUntil == nil || until.Time.After(*lockedUntil) {
+			t := until.Time
+			lockedUntil = &t
+		}
+	}
+	return lockedUntil, nil
+}
+
+// RecordFailure suma un intent fallit i retorna quants n'hi ha. El comptador torna a
+// començar quan ha passat la finestra des de l'últim error i des del final de l'últim
+// bloqueig, perquè el bloqueig pugui seguir creixent encara que duri més que la finestra.
+// Si ip no és buida, s'afegeix a les IPs relacionades amb la clau.
+func (r *throttleRepository) RecordFailure(ctx context.Context, key, ip string, window time.Duration) (int, error) {
+	ips := []string{}
+	if ip != "" {
+		ips = append(ips, ip)
+	}
+	var failures int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO login_throttles (key, failures, last_failure_at, ips)
+		VALUES ($1, 1, NOW(), $3)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN GREATEST(login_throttles.last_failure_at, login_throttles.locked_until) < NOW() - $2 * INTERVAL '1 second' THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			ips = CASE
+				WHEN GREATEST(login_throttles.last_failure_at, login_throttles.locked_until) < NOW() - $2 * INTERVAL '1 second' THEN EXCLUDED.ips
+				ELSE ARRAY(SELECT DISTINCT unnest(login_throttles.ips || EXCLUDED.ips))
+			END,
+			last_failure_at = NOW()
+		RETURNING failures`,
+		key, int(window/time.Second), pq.Array(ips),
+	).Scan(&failures)
+	return failures, err
+}
+
+func (r *throttleRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE login_throttles
+		SET locked_until = $2
+		WHERE key = $1`,
+		key, until,
+	)
+	return err
+}
+
+func (r *throttleRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM login_throttles
+		WHERE key = $1`,
+		key,
+	)
+	return err
+}
+
+// ResetWithIPs esborra la clau i les claus de les IPs des d'on s'hi ha fallat.
+func (r *throttleRepository) ResetWithIPs(ctx context.Context, key string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM login_throttles
+		WHERE key IN (
+			SELECT 'ip:' || unnest(ips)
+			FROM login_throttles
+			WHERE key = $1)`,
+		key,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM login_throttles WHERE key = $1`, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
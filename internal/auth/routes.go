@@ -1,11 +1,19 @@
 package auth
 
 import (
+	"orkestra-api/middleware"
+
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterRoutes(router *gin.RouterGroup, handler *AuthHandler, jwtMiddleware *jwt.GinJWTMiddleware) {
 	router.POST("/login", handler.Login)
-	router.GET("/refresh_token", jwtMiddleware.RefreshHandler)
-}
\ No newline at end of file
+	router.POST("/login/2fa", handler.LoginTwoFactor)
+	router.GET("/refresh_token", handler.RefreshToken)
+}
+
+// RegisterAdminRoutes registra les rutes d'auth que necessiten un usuari autenticat
+func RegisterAdminRoutes(router *gin.RouterGroup, handler *AuthHandler, authz *middleware.Authorizer) {
+	router.DELETE("/users/:id/lockout", authz.Require("users:write"), handler.Unlock)
+}
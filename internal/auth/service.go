@@ -2,71 +2,207 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"orkestra-api/config"
+	"orkestra-api/internal/sessions"
+	"orkestra-api/internal/twofactor"
 	"orkestra-api/internal/users"
+	"orkestra-api/internal/usertokens"
+	"orkestra-api/middleware"
 
+	"sync"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-
+// twoFactorChallengeTTL és el temps que té l'usuari per introduir el codi del segon factor.
+const twoFactorChallengeTTL = 5 * time.Minute
 
 type AuthService interface {
-    Login(ctx context.Context, req LoginRequest) (string, users.User, time.Time, error)
+    Login(ctx context.Context, req LoginRequest) (LoginResponse, error)
+    LoginTwoFactor(ctx context.Context, req TwoFactorLoginRequest) (LoginResponse, error)
     ValidateUser(username, password string) (users.User, error)
+    RefreshSession(ctx context.Context, sessionID string) error
+    Unlock(ctx context.Context, id string) error
 }
 
 type authService struct {
     userRepo users.UserRepository
+    throttleRepo ThrottleRepository
     jwtMiddleware *jwt.GinJWTMiddleware
+    sessionService sessions.SessionService
+    tokenService usertokens.TokenService
+    twoFactorService twofactor.TwoFactorService
+    audit AuditLogger
+    cfg config.Config
 }
 
-func NewAuthService(userRepo users.UserRepository,  jwtMiddleware *jwt.GinJWTMiddleware) AuthService {
+func NewAuthService(userRepo users.UserRepository, throttleRepo ThrottleRepository, jwtMiddleware *jwt.GinJWTMiddleware, sessionService sessions.SessionService, tokenService usertokens.TokenService, twoFactorService twofactor.TwoFactorService, audit AuditLogger, cfg config.Config) AuthService {
     return &authService{
         userRepo: userRepo,
+        throttleRepo: throttleRepo,
         jwtMiddleware: jwtMiddleware,
+        sessionService: sessionService,
+        tokenService: tokenService,
+        twoFactorService: twoFactorService,
+        audit: audit,
+        cfg: cfg,
     }
 }
 
-// Login verifica les credencials i retorna un token JWT si són vàlides
-func (s *authService) Login(ctx context.Context, req LoginRequest) (string, users.User, time.Time, error) {
+// Login verifica les credencials i retorna un token JWT si són vàlides. Si l'usuari té
+// segon factor (o el seu perfil l'exigeix) retorna un token de repte en lloc del JWT
+func (s *authService) Login(ctx context.Context, req LoginRequest) (LoginResponse, error) {
+    // Mentre el nom d'usuari o la IP estan bloquejats ni tan sols es comprova la contrasenya
+    if err := s.checkThrottle(ctx, req.Username, req.IP); err != nil {
+        return LoginResponse{}, err
+    }
+
     // Validar les credencials
     user, err := s.ValidateUser(req.Username, req.Password)
+    if err == ErrInvalidCredentials {
+        s.recordFailure(ctx, req.Username, req.IP)
+    }
+    if err != nil {
+        return LoginResponse{}, err
+    }
+
+    status, err := s.twoFactorService.Status(ctx, user.ID.String())
+    if err != nil {
+        return LoginResponse{}, err
+    }
+    if !status.Enabled && !status.Required {
+        s.resetThrottle(ctx, user.Username)
+        return s.issueToken(ctx, user, req.UserAgent, req.IP)
+    }
+    // Amb segon factor el comptador no es reinicia fins que el codi és correcte
+
+    challenge, err := s.tokenService.Issue(ctx, user.ID, usertokens.PurposeTwoFactorLogin, twoFactorChallengeTTL)
+    if err != nil {
+        return LoginResponse{}, err
+    }
+    response := LoginResponse{TwoFactorRequired: true, ChallengeToken: challenge}
+    if !status.Enabled {
+        // El perfil l'exigeix i encara no el té: l'alta es confirma amb el codi del segon pas.
+        // Qui només sap la contrasenya podria donar d'alta el seu autenticador, així que cada
+        // secret lliurat compta com un intent fallit fins que l'alta es confirma
+        s.recordFailure(ctx, user.Username, req.IP)
+        enrolment, err := s.twoFactorService.Enroll(ctx, user.ID.String())
+        if err != nil {
+            return LoginResponse{}, err
+        }
+        response.Enrolment = &enrolment
+    }
+    return response, nil
+}
+
+// LoginTwoFactor completa el login amb el token de repte i el codi. El token de repte
+// és d'un sol ús: si el codi és incorrecte cal tornar a començar amb la contrasenya
+func (s *authService) LoginTwoFactor(ctx context.Context, req TwoFactorLoginRequest) (LoginResponse, error) {
+    userID, err := s.tokenService.Consume(ctx, usertokens.PurposeTwoFactorLogin, req.ChallengeToken)
+    if err == usertokens.ErrInvalidToken {
+        return LoginResponse{}, ErrInvalidChallenge
+    }
+    if err != nil {
+        return LoginResponse{}, err
+    }
+    user, err := s.userRepo.FindByID(ctx, userID)
+    if err != nil {
+        return LoginResponse{}, err
+    }
+    if err := s.checkThrottle(ctx, user.Username, req.IP); err != nil {
+        return LoginResponse{}, err
+    }
+
+    status, err := s.twoFactorService.Status(ctx, userID.String())
     if err != nil {
-        return "", users.User{}, time.Time{}, err
+        return LoginResponse{}, err
+    }
+    if status.Enabled {
+        err = s.twoFactorService.Verify(ctx, userID.String(), req.Code)
+    } else {
+        err = s.twoFactorService.Confirm(ctx, userID.String(), req.Code)
+    }
+    if errors.Is(err, twofactor.ErrInvalidCode) {
+        s.recordFailure(ctx, user.Username, req.IP)
+    }
+    if err != nil {
+        return LoginResponse{}, err
+    }
+    s.resetThrottle(ctx, user.Username)
+    return s.issueToken(ctx, user, req.UserAgent, req.IP)
+}
+
+// issueToken obre una sessió i genera el JWT
+func (s *authService) issueToken(ctx context.Context, user users.User, userAgent, ip string) (LoginResponse, error) {
+    // Cada login obre una sessió que es pot revocar
+    session, err := s.sessionService.Start(ctx, user.ID, userAgent, ip)
+    if err != nil {
+        return LoginResponse{}, err
     }
-    id := user.ID.String()
     // Generar token JWT
-    token, expire, err := s.jwtMiddleware.TokenGenerator(id)
+    token, expire, err := s.jwtMiddleware.TokenGenerator(middleware.TokenClaims{
+        UserID:    user.ID.String(),
+        SessionID: session.ID.String(),
+    })
     if err != nil {
-        return "", users.User{}, time.Time{}, err
+        return LoginResponse{}, err
     }
-   
-    user.Password = "" // No retornar la contrasenya en la resposta
-    return token, user,  expire, nil
+
+    response := users.MapUserToResponse(user)
+    return LoginResponse{
+        Token:  token,
+        Expire: expire.Format(time.RFC3339),
+        User:   &response,
+    }, nil
 }
 
-// ValidateUser verifica si les credencials són vàlides i retorna l'ID de l'usuari
+// ValidateUser verifica si les credencials són vàlides i retorna l'ID de l'usuari.
+// Un usuari inexistent, desactivat o amb la contrasenya incorrecta donen el mateix error
+// i tarden el mateix, perquè no es puguin endevinar els noms d'usuari
 func (s *authService) ValidateUser(username, password string) (users.User, error) {
-    // Obtenir l'usuari per nom d'usuari
+    // Obtenir l'usuari per nom d'usuari (el repositori retorna error si està desactivat)
     user, err := s.userRepo.FindByUsername(context.Background(), username)
+    if err != nil && err != users.ErrUserNotFound && err != users.ErrInactiveUser {
+        return users.User{}, err
+    }
 
+    hash := []byte(user.Password)
     if err != nil {
-        return users.User{}, ErrUserNotFound
-    }
-    
-    // Verificar que l'usuari estigui actiu
-    if !user.IsActive {
-        return users.User{}, users.ErrInactiveUser
+        hash = dummyPasswordHash()
     }
     
     // Verificar la contrasenya
-    err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-    if err != nil {
+    if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || err != nil {
         return users.User{}, ErrInvalidCredentials
     }
+
+    // Amb REQUIRE_VERIFIED_EMAIL només poden entrar els usuaris que han verificat el correu
+    if s.cfg.RequireVerifiedEmail && !user.IsVerified {
+        return users.User{}, ErrEmailNotVerified
+    }
     
     // Retornar l'ID de l'usuari com a identificador principal
     return user, nil
-}
\ No newline at end of file
+}
+
+// RefreshSession comprova que la sessió del token encara és vigent i l'allarga.
+func (s *authService) RefreshSession(ctx context.Context, sessionID string) error {
+    return s.sessionService.Extend(ctx, sessionID)
+}
+
+var (
+    dummyHash     []byte
+    dummyHashOnce sync.Once
+)
+
+// dummyPasswordHash és un hash amb el mateix cost que els reals, per comparar-hi quan
+// l'usuari no existeix
+func dummyPasswordHash() []byte {
+    dummyHashOnce.Do(func() {
+        dummyHash, _ = bcrypt.GenerateFromPassword([]byte("orkestra-dummy-password"), bcrypt.DefaultCost)
+    })
+    return dummyHash
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLogger desa entrades a action_logs (middleware.ActionLogMiddleware).
+type AuditLogger interface {
+	SaveActionLog(userID uuid.UUID, actionType, metadata, timezone string, performedAt time.Time) error
+}
+
+// Els intents fallits es compten per nom d'usuari i per IP, cadascun amb el seu llindar.
+func userThrottleKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+func throttleKeys(username, ip string) []string {
+	keys := []string{userThrottleKey(username)}
+	if ip != "" {
+		keys = append(keys, ipThrottleKey(ip))
+	}
+	return keys
+}
+
+// checkThrottle retorna un LockedError si el nom d'usuari o la IP estan bloquejats.
+func (s *authService) checkThrottle(ctx context.Context, username, ip string) error {
+	lockedUntil, err := s.throttleRepo.LockedUntil(ctx, throttleKeys(username, ip)...)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		return &LockedError{Until: *lockedUntil}
+	}
+	return nil
+}
+
+// recordFailure compta l'intent fallit i, si se supera el llindar, bloqueja la clau.
+// Un error aquí no ha de canviar la resposta del login.
+func (s *authService) recordFailure(ctx context.Context, username, ip string) {
+	thresholds := map[string]int{userThrottleKey(username): s.cfg.LoginMaxAttempts}
+	if ip != "" {
+		thresholds[ipThrottleKey(ip)] = s.cfg.LoginMaxAttemptsPerIP
+	}
+	window := time.Duration(s.cfg.LoginAttemptWindowSeconds) * time.Second
+
+	for key, threshold := range thresholds {
+		// Al nom d'usuari s'hi guarda la IP perquè Unlock també la pugui desbloquejar
+		relatedIP := ""
+		if key == userThrottleKey(username) {
+			relatedIP = ip
+		}
+		failures, err := s.throttleRepo.RecordFailure(ctx, key, relatedIP, window)
+		if err != nil {
+			log.Printf("Error recording failed login for %s: %v", key, err)
+			continue
+		}
+		if threshold <= 0 || failures < threshold {
+			continue
+		}
+		until := time.Now().Add(s.lockoutDuration(failures - threshold))
+		if err := s.throttleRepo.Lock(ctx, key, until); err != nil {
+			log.Printf("Error locking %s: %v", key, err)
+			continue
+		}
+		s.auditLockout(key, failures, until)
+	}
+}
+
+// resetThrottle reinicia el comptador del nom d'usuari quan el login s'ha completat.
+func (s *authService) resetThrottle(ctx context.Context, username string) {
+	if err := s.throttleRepo.Reset(ctx, userThrottleKey(username)); err != nil {
+		log.Printf("Error resetting login throttle: %v", err)
+	}
+}
+
+// lockoutDuration dobla el bloqueig per cada intent fallit per sobre del llindar, fins al màxim.
+func (s *authService) lockoutDuration(extraFailures int) time.Duration {
+	base := time.Duration(s.cfg.LoginLockoutSeconds) * time.Second
+	max := time.Duration(s.cfg.LoginMaxLockoutSeconds) * time.Second
+	if extraFailures > 30 {
+		return max
+	}
+	duration := time.Duration(float64(base) * math.Pow(2, float64(extraFailures)))
+	if duration > max {
+		return max
+	}
+	return duration
+}
+
+func (s *authService) auditLockout(key string, failures int, until time.Time) {
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"key":          key,
+		"failures":     failures,
+		"locked_until": until.Format(time.RFC3339),
+	})
+	if err := s.audit.SaveActionLog(uuid.Nil, "LOGIN_LOCKOUT", string(metadata), "", time.Now()); err != nil {
+		log.Printf("Error saving lockout audit entry: %v", err)
+	}
+}
+
+// Unlock treu el bloqueig del nom d'usuari de l'usuari id i de les IPs des d'on s'hi ha fallat.
+func (s *authService) Unlock(ctx context.Context, id string) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.throttleRepo.ResetWithIPs(ctx, userThrottleKey(user.Username))
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"orkestra-api/config"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	s := &authService{cfg: config.Config{LoginLockoutSeconds: 60, LoginMaxLockoutSeconds: 3600}}
+
+	tests := []struct {
+		extra int
+		want  time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{2, 4 * time.Minute},
+		{5, 32 * time.Minute},
+		{6, time.Hour},
+		{31, time.Hour},
+		{1000, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := s.lockoutDuration(tt.extra); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", tt.extra, got, tt.want)
+		}
+	}
+}
+
+// memoryThrottle és un ThrottleRepository en memòria que no reinicia mai el comptador.
+type memoryThrottle struct {
+	failures map[string]int
+	locked   map[string]time.Time
+}
+
+func (m *memoryThrottle) LockedUntil(ctx context.Context, keys ...string) (*time.Time, error) {
+	for _, key := range keys {
+		if until, ok := m.locked[key]; ok {
+			return &until, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryThrottle) RecordFailure(ctx context.Context, key, ip string, window time.Duration) (int, error) {
+	m.failures[key]++
+	return m.failures[key], nil
+}
+
+func (m *memoryThrottle) Lock(ctx context.Context, key string, until time.Time) error {
+	m.locked[key] = until
+	return nil
+}
+
+func (m *memoryThrottle) Reset(ctx context.Context, key string) error {
+	delete(m.failures, key)
+	delete(m.locked, key)
+	return nil
+}
+
+func (m *memoryThrottle) ResetWithIPs(ctx context.Context, key string) error {
+	return m.Reset(ctx, key)
+}
+
+type discardAudit struct{}
+
+func (discardAudit) SaveActionLog(userID uuid.UUID, actionType, metadata, timezone string, performedAt time.Time) error {
+	return nil
+}
+
+func TestRecordFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		userLock time.Duration
+		ipLock   time.Duration
+	}{
+		{name: "below both thresholds", failures: 2},
+		{name: "user threshold", failures: 3, userLock: time.Minute},
+		{name: "user backoff", failures: 5, userLock: 4 * time.Minute},
+		{name: "ip threshold", failures: 6, userLock: 8 * time.Minute, ipLock: time.Minute},
+		{name: "capped", failures: 12, userLock: 10 * time.Minute, ipLock: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryThrottle{failures: map[string]int{}, locked: map[string]time.Time{}}
+			s := &authService{throttleRepo: repo, audit: discardAudit{}, cfg: config.Config{
+				LoginMaxAttempts:       3,
+				LoginMaxAttemptsPerIP:  6,
+				LoginLockoutSeconds:    60,
+				LoginMaxLockoutSeconds: 600,
+			}}
+
+			for i := 0; i < tt.failures; i++ {
+				s.recordFailure(context.Background(), "Anna ", "10.0.0.1")
+			}
+			checkLock(t, repo, userThrottleKey("anna"), tt.userLock)
+			checkLock(t, repo, ipThrottleKey("10.0.0.1"), tt.ipLock)
+		})
+	}
+}
+
+func checkLock(t *testing.T, repo *memoryThrottle, key string, want time.Duration) {
+	t.Helper()
+	until, locked := repo.locked[key]
+	if want == 0 {
+		if locked {
+			t.Errorf("%s locked until %v, want unlocked", key, until)
+		}
+		return
+	}
+	if !locked {
+		t.Fatalf("%s is not locked, want %v", key, want)
+	}
+	if got := time.Until(until); got > want || got < want-time.Minute/2 {
+		t.Errorf("%s locked for %v, want %v", key, got.Round(time.Second), want)
+	}
+}
@@ -0,0 +1,8 @@
+package calendarfeeds
+
+// CreatedCalendarFeed porta el token en clar i l'URL de subscripció. Només es retorna en crear-la.
+type CreatedCalendarFeed struct {
+	CalendarFeed
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
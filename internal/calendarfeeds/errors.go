@@ -0,0 +1,9 @@
+package calendarfeeds
+
+import "errors"
+
+var (
+	ErrInvalidID    = errors.New("invalid user ID")
+	ErrFeedNotFound = errors.New("calendar feed not found")
+	ErrInvalidToken = errors.New("invalid or revoked calendar feed token")
+)
@@ -0,0 +1,84 @@
+package calendarfeeds
+
+import (
+	"errors"
+	"net/http"
+	"orkestra-api/internal/ical"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarFeedHandler struct {
+	service CalendarFeedService
+}
+
+func NewCalendarFeedHandler(service CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		service: service,
+	}
+}
+
+func (h *CalendarFeedHandler) GetMyFeed(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	feed, err := h.service.FindByUserID(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+func (h *CalendarFeedHandler) RotateMyFeed(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	feed, err := h.service.Rotate(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, feed)
+}
+
+func (h *CalendarFeedHandler) DeleteMyFeed(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	if err := h.service.Revoke(c.Request.Context(), userID.(string)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetFeed serveix el calendari del token. L'extensió .ics és opcional.
+func (h *CalendarFeedHandler) GetFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	calendar, err := h.service.Calendar(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	ical.Render(c, "orkestra.ics", calendar)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrFeedNotFound), errors.Is(err, ErrInvalidToken):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,25 @@
+package calendarfeeds
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeed és la subscripció iCalendar d'un usuari. Cada usuari en té com a molt una
+// d'activa i només se'n desa el hash del token.
+type CalendarFeed struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+// FeedOwner és l'usuari al qual pertany un token de subscripció.
+type FeedOwner struct {
+	FeedID     uuid.UUID
+	UserID     uuid.UUID
+	IsAdmin    bool
+	LastUsedAt *time.Time
+}
@@ -0,0 +1,116 @@
+package calendarfeeds
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type CalendarFeedRepository interface {
+	Rotate(ctx context.Context, feed CalendarFeed) (CalendarFeed, error)
+	FindActiveByUserID(ctx context.Context, userID uuid.UUID) (CalendarFeed, error)
+	Revoke(ctx context.Context, userID uuid.UUID) error
+	FindOwnerByTokenHash(ctx context.Context, tokenHash string) (FeedOwner, error)
+	Touch(ctx context.Context, id uuid.UUID) error
+}
+
+type calendarFeedRepository struct {
+	db *sql.DB
+}
+
+func NewCalendarFeedRepository(db *sql.DB) CalendarFeedRepository {
+	return &calendarFeedRepository{db: db}
+}
+
+// Rotate revoca la subscripció activa de l'usuari i en crea una de nova en la mateixa transacció.
+func (r *calendarFeedRepository) Rotate(ctx context.Context, feed CalendarFeed) (CalendarFeed, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return CalendarFeed{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE calendar_feeds
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL`,
+		feed.UserID,
+	); err != nil {
+		return CalendarFeed{}, err
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO calendar_feeds (id, user_id, token_hash, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING created_at`,
+		feed.ID, feed.UserID, feed.TokenHash,
+	).Scan(&feed.CreatedAt)
+	if err != nil {
+		return CalendarFeed{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return CalendarFeed{}, err
+	}
+	return feed, nil
+}
+
+func (r *calendarFeedRepository) FindActiveByUserID(ctx context.Context, userID uuid.UUID) (CalendarFeed, error) {
+	var feed CalendarFeed
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, created_at, last_used_at
+		FROM calendar_feeds
+		WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID,
+	).Scan(&feed.ID, &feed.UserID, &feed.CreatedAt, &feed.LastUsedAt)
+	if err != nil {
+		return CalendarFeed{}, err
+	}
+	return feed, nil
+}
+
+func (r *calendarFeedRepository) Revoke(ctx context.Context, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE calendar_feeds
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FindOwnerByTokenHash només troba subscripcions actives d'usuaris actius.
+func (r *calendarFeedRepository) FindOwnerByTokenHash(ctx context.Context, tokenHash string) (FeedOwner, error) {
+	var owner FeedOwner
+	err := r.db.QueryRowContext(ctx, `
+		SELECT f.id, f.user_id, COALESCE(p.is_admin, false), f.last_used_at
+		FROM calendar_feeds f
+			INNER JOIN users u ON u.id = f.user_id
+			LEFT JOIN profiles p ON p.id = u.profile_id
+		WHERE f.token_hash = $1 AND f.revoked_at IS NULL AND u.is_active`,
+		tokenHash,
+	).Scan(&owner.FeedID, &owner.UserID, &owner.IsAdmin, &owner.LastUsedAt)
+	if err != nil {
+		return FeedOwner{}, err
+	}
+	return owner, nil
+}
+
+func (r *calendarFeedRepository) Touch(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE calendar_feeds
+		SET last_used_at = NOW()
+		WHERE id = $1`,
+		id,
+	)
+	return err
+}
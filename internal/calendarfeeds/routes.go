@@ -0,0 +1,22 @@
+package calendarfeeds
+
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registra la gestió de la subscripció de l'usuari que fa la petició.
+func RegisterRoutes(router *gin.RouterGroup, handler *CalendarFeedHandler) {
+	feed := router.Group("/me/calendar-feed", middleware.RejectAPITokens)
+	{
+		feed.GET("", handler.GetMyFeed)
+		feed.POST("", handler.RotateMyFeed)
+		feed.DELETE("", handler.DeleteMyFeed)
+	}
+}
+
+// RegisterPublicRoutes registra el calendari de subscripció, que s'autentica amb el token de l'URL.
+func RegisterPublicRoutes(router *gin.RouterGroup, handler *CalendarFeedHandler) {
+	router.GET("/calendar/:token", handler.GetFeed)
+}
@@ -0,0 +1,154 @@
+package calendarfeeds
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log"
+	"orkestra-api/config"
+	"orkestra-api/internal/groups"
+	"orkestra-api/internal/ical"
+	"orkestra-api/internal/meetings"
+	"orkestra-api/internal/projects"
+	"orkestra-api/internal/tasks"
+	"orkestra-api/internal/usertokens"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CalendarFeedService interface {
+	FindByUserID(ctx context.Context, userID string) (CalendarFeed, error)
+	Rotate(ctx context.Context, userID string) (CreatedCalendarFeed, error)
+	Revoke(ctx context.Context, userID string) error
+	Calendar(ctx context.Context, token string) (ical.Calendar, error)
+}
+
+// feedTouchInterval evita escriure last_used_at cada cop que el client del calendari consulta.
+const feedTouchInterval = time.Minute
+
+type calendarFeedService struct {
+	repo           CalendarFeedRepository
+	meetingService meetings.MeetingService
+	taskService    tasks.TaskService
+	projectService projects.ProjectService
+	cfg            config.Config
+}
+
+func NewCalendarFeedService(repo CalendarFeedRepository, meetingService meetings.MeetingService, taskService tasks.TaskService, projectService projects.ProjectService, cfg config.Config) CalendarFeedService {
+	return &calendarFeedService{
+		repo:           repo,
+		meetingService: meetingService,
+		taskService:    taskService,
+		projectService: projectService,
+		cfg:            cfg,
+	}
+}
+
+func (s *calendarFeedService) FindByUserID(ctx context.Context, userID string) (CalendarFeed, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return CalendarFeed{}, ErrInvalidID
+	}
+	feed, err := s.repo.FindActiveByUserID(ctx, userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CalendarFeed{}, ErrFeedNotFound
+	}
+	return feed, err
+}
+
+// Rotate crea una subscripció nova i invalida l'anterior. El token en clar només es
+// retorna aquí, dins l'URL que s'ha de copiar al client de calendari.
+func (s *calendarFeedService) Rotate(ctx context.Context, userID string) (CreatedCalendarFeed, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return CreatedCalendarFeed{}, ErrInvalidID
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return CreatedCalendarFeed{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	feed, err := s.repo.Rotate(ctx, CalendarFeed{
+		ID:        uuid.New(),
+		UserID:    userUUID,
+		TokenHash: usertokens.HashToken(token),
+	})
+	if err != nil {
+		return CreatedCalendarFeed{}, err
+	}
+	return CreatedCalendarFeed{
+		CalendarFeed: feed,
+		Token:        token,
+		URL:          s.cfg.APIURL + "/feeds/calendar/" + token + ".ics",
+	}, nil
+}
+
+func (s *calendarFeedService) Revoke(ctx context.Context, userID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	err = s.repo.Revoke(ctx, userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrFeedNotFound
+	}
+	return err
+}
+
+// Calendar construeix el calendari del propietari del token: les reunions dels seus grups,
+// les seves tasques amb data i, si és administrador, les assignacions d'operaris.
+func (s *calendarFeedService) Calendar(ctx context.Context, token string) (ical.Calendar, error) {
+	owner, err := s.repo.FindOwnerByTokenHash(ctx, usertokens.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ical.Calendar{}, ErrInvalidToken
+	}
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+	if owner.LastUsedAt == nil || time.Since(*owner.LastUsedAt) > feedTouchInterval {
+		if err := s.repo.Touch(ctx, owner.FeedID); err != nil {
+			log.Printf("Error updating calendar feed %s: %v", owner.FeedID, err)
+		}
+	}
+
+	const layout = "2006-01-02"
+	now := time.Now()
+	windowStart := now.AddDate(0, 0, -s.cfg.CalendarFeedPastDays)
+	windowEnd := now.AddDate(0, 0, s.cfg.CalendarFeedFutureDays)
+
+	// El feed només mostra les reunions dels grups de l'usuari, encara que sigui administrador
+	caller := groups.Caller{UserID: owner.UserID}
+	meetingList, err := s.meetingService.FindBetweenDates(ctx, caller, windowStart.Format(layout), windowEnd.Format(layout), "")
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+	events := meetings.CalendarEvents(meetingList)
+
+	taskList, err := s.taskService.FindByUserID(ctx, owner.UserID.String())
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+	datedTasks := make([]tasks.Task, 0, len(taskList))
+	for _, task := range taskList {
+		start, end, ok := task.Span()
+		if !ok || start.After(windowEnd) || end.Before(windowStart) {
+			continue
+		}
+		datedTasks = append(datedTasks, task)
+	}
+	events = append(events, tasks.CalendarEvents(datedTasks)...)
+
+	if owner.IsAdmin {
+		allocations, err := s.projectService.FindOperatorsCalendarBetweenDates(ctx, windowStart.Format(layout), windowEnd.Format(layout))
+		if err != nil {
+			return ical.Calendar{}, err
+		}
+		events = append(events, projects.OperatorEvents(allocations)...)
+	}
+
+	return ical.Calendar{Name: "Orkestra", Events: events}, nil
+}
@@ -2,16 +2,17 @@ package costitems
 
 import (
 	"fmt"
+	"orkestra-api/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.RouterGroup, handler *CostItemHandler){
+func RegisterRoutes(router *gin.RouterGroup, handler *CostItemHandler, authz *middleware.Authorizer){
 	prefix := "/costitems"
-	router.POST(prefix, handler.Create)
-	router.PUT(fmt.Sprintf("%s/:id", prefix), handler.Update)
-	router.DELETE(fmt.Sprintf("%s/:id", prefix), handler.Delete)
-	router.GET(fmt.Sprintf("%s/:id", prefix), handler.GetByID)
-	router.GET(fmt.Sprintf("%s/project/:projectid", prefix), handler.GetByProjectID)
-	router.GET(prefix, handler.GetAll)
+	router.POST(prefix, authz.Require("costitems:write"), handler.Create)
+	router.PUT(fmt.Sprintf("%s/:id", prefix), authz.Require("costitems:write"), handler.Update)
+	router.DELETE(fmt.Sprintf("%s/:id", prefix), authz.Require("costitems:write"), handler.Delete)
+	router.GET(fmt.Sprintf("%s/:id", prefix), authz.Require("costitems:read"), handler.GetByID)
+	router.GET(fmt.Sprintf("%s/project/:projectid", prefix), authz.Require("costitems:read"), handler.GetByProjectID)
+	router.GET(prefix, authz.Require("costitems:read"), handler.GetAll)
 }
\ No newline at end of file
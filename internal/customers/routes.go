@@ -1,14 +1,18 @@
 package customers
 
-import "github.com/gin-gonic/gin"
+import (
+	"orkestra-api/middleware"
 
-func RegisterRoutes(router *gin.RouterGroup, handler *CustomerHandler){
-	router.POST("/customers", handler.CreateCustomer)
-	router.PUT("/customers/:id", handler.UpdateCustomer)
-	router.DELETE("/customers/:id", handler.DeleteCustomer)
-	router.GET("/customers/:id", handler.GetCustomerByID)
-	router.GET("/customers", handler.GetAllCustomers)
-	router.POST("/customers/adduser", handler.AddUserToCustomer)
-	router.POST("/customers/removeuser", handler.RemoveUserFromCustomer)
-	router.GET("/customers/userbycustomer/:id", handler.GetUsersByCustomerID)
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *CustomerHandler, authz *middleware.Authorizer){
+	router.POST("/customers", authz.Require("customers:write"), handler.CreateCustomer)
+	router.PUT("/customers/:id", authz.Require("customers:write"), handler.UpdateCustomer)
+	router.DELETE("/customers/:id", authz.Require("customers:write"), handler.DeleteCustomer)
+	router.GET("/customers/:id", authz.Require("customers:read"), handler.GetCustomerByID)
+	router.GET("/customers", authz.Require("customers:read"), handler.GetAllCustomers)
+	router.POST("/customers/adduser", authz.Require("customers:write"), handler.AddUserToCustomer)
+	router.POST("/customers/removeuser", authz.Require("customers:write"), handler.RemoveUserFromCustomer)
+	router.GET("/customers/userbycustomer/:id", authz.Require("customers:read"), handler.GetUsersByCustomerID)
 }
\ No newline at end of file
@@ -3,3 +3,7 @@ package groups
 type GroupRequest struct {
 	Name string `json:"name" binding:"required"`
 }
+
+type GroupAdminRequest struct {
+	IsAdmin bool `json:"is_admin"`
+}
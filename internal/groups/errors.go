@@ -7,4 +7,8 @@ var (
 	ErrInvalidID      = errors.New("invalid group ID")
 	ErrGroupNameTaken = errors.New("group name already taken")
 	ErrInvalidRequest = errors.New("invalid request")
+	ErrNotGroupMember = errors.New("you are not a member of this group")
+	ErrGroupAdminRequired = errors.New("only group admins can do this")
+	ErrUserNotInGroup = errors.New("user is not a member of this group")
+	ErrLastGroupAdmin = errors.New("a group needs at least one admin")
 )
\ No newline at end of file
@@ -1,9 +1,13 @@
 package groups
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"orkestra-api/middleware"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type GroupHandler struct {
@@ -22,9 +26,14 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	group, err := h.groupService.Create(c.Request.Context(), request)
+	caller, ok := CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	group, err := h.groupService.Create(c.Request.Context(), caller, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -38,10 +47,15 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	caller, ok := CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
 
-	group, err := h.groupService.Update(c.Request.Context(), id, request)
+	group, err := h.groupService.Update(c.Request.Context(), caller, id, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -50,9 +64,14 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 
 func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 	id := c.Param("id")
-	err := h.groupService.Delete(c.Request.Context(), id)
+	caller, ok := CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.groupService.Delete(c.Request.Context(), caller, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -94,9 +113,14 @@ func (h *GroupHandler) GetAllGroups(c *gin.Context) {
 func (h *GroupHandler) AddUserToGroup(c *gin.Context) {
 	id := c.Param("id")
 	userID := c.Param("userID")
-	err := h.groupService.AddUserToGroup(c.Request.Context(), id, userID)
+	caller, ok := CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.groupService.AddUserToGroup(c.Request.Context(), caller, id, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -106,11 +130,67 @@ func (h *GroupHandler) AddUserToGroup(c *gin.Context) {
 func (h *GroupHandler) RemoveUserFromGroup(c *gin.Context) {
 	id := c.Param("id")
 	userID := c.Param("userID")
-	err := h.groupService.RemoveUserFromGroup(c.Request.Context(), id, userID)
+	caller, ok := CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.groupService.RemoveUserFromGroup(c.Request.Context(), caller, id, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SetGroupAdmin fa o desfà administrador del grup un membre
+func (h *GroupHandler) SetGroupAdmin(c *gin.Context) {
+	var request GroupAdminRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	caller, ok := CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.groupService.SetAdmin(c.Request.Context(), caller, c.Param("id"), c.Param("userID"), request.IsAdmin)
+	if err != nil {
+		c.JSON(ErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
+}
+
+// CallerFromContext construeix el Caller amb l'usuari de la petició. Admin només és cert si
+// un Require anterior de la ruta ha carregat els permisos.
+func CallerFromContext(c *gin.Context) (Caller, bool) {
+	userID, exists := c.Get("id")
+	if !exists {
+		return Caller{}, false
+	}
+	id, err := uuid.Parse(userID.(string))
+	if err != nil {
+		return Caller{}, false
+	}
+	return Caller{UserID: id, Admin: middleware.IsAdmin(c)}, true
+}
+
+// ErrorStatus tradueix els errors de grup, que també retornen altres paquets com meetings.
+func ErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotGroupMember), errors.Is(err, ErrGroupAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, ErrGroupNotFound), errors.Is(err, ErrUserNotInGroup), errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, ErrGroupNameTaken), errors.Is(err, ErrLastGroupAdmin):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
\ No newline at end of file
@@ -6,4 +6,18 @@ type Group struct {
 	ID uuid.UUID `json:"id" db:"id"`
 	Name string    `json:"name" db:"name"`
 	CreatedAt string `json:"created_at" db:"created_at"`
-}
\ No newline at end of file
+}
+
+// Membership és el rol d'un usuari en un grup. Només els administradors del grup
+// poden editar-ne o esborrar-ne les reunions i gestionar-ne els membres.
+type Membership struct {
+	Member bool `json:"member"`
+	Admin  bool `json:"admin"`
+}
+
+// Caller és l'usuari que fa la petició. Admin indica un perfil d'administrador, que no
+// depèn dels rols de grup.
+type Caller struct {
+	UserID uuid.UUID
+	Admin  bool
+}
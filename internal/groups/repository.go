@@ -15,8 +15,11 @@ type GroupRepository interface {
 	FindByName(ctx context.Context, name string) (Group, error)
 	FindAll(ctx context.Context) ([]Group, error)
 	FindByUserID(ctx context.Context, userID uuid.UUID) ([]Group, error)
-	AddUserToGroup(ctx context.Context, id, groupID, userID uuid.UUID) error
+	AddUserToGroup(ctx context.Context, id, groupID, userID uuid.UUID, isAdmin bool) error
 	RemoveUserFromGroup(ctx context.Context, groupID, userID uuid.UUID) error
+	FindMembership(ctx context.Context, groupID, userID uuid.UUID) (Membership, error)
+	SetAdmin(ctx context.Context, groupID, userID uuid.UUID, isAdmin bool) error
+	CountAdmins(ctx context.Context, groupID uuid.UUID) (int, error)
 }
 
 type groupRepository struct {
@@ -148,11 +151,11 @@ func (r *groupRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([
 	return groups, nil
 }
 
-func (r *groupRepository) AddUserToGroup(ctx context.Context,id, groupID, userID uuid.UUID) error {
+func (r *groupRepository) AddUserToGroup(ctx context.Context,id, groupID, userID uuid.UUID, isAdmin bool) error {
 	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO group_members (id, group_id, user_id, joined_at)
-		VALUES ($1, $2, $3, NOW())`,
-		id, groupID, userID,
+		INSERT INTO group_members (id, group_id, user_id, joined_at, is_admin)
+		VALUES ($1, $2, $3, NOW(), $4)`,
+		id, groupID, userID, isAdmin,
 	)
 	if err != nil {
 		return err
@@ -170,4 +173,55 @@ func (r *groupRepository) RemoveUserFromGroup(ctx context.Context, groupID, user
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// FindMembership retorna si l'usuari és membre del grup i si n'és administrador.
+func (r *groupRepository) FindMembership(ctx context.Context, groupID, userID uuid.UUID) (Membership, error) {
+	var membership Membership
+	err := r.db.QueryRowContext(ctx, `
+		SELECT TRUE, COALESCE(bool_or(is_admin), FALSE)
+		FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+		HAVING COUNT(*) > 0`,
+		groupID, userID,
+	).Scan(&membership.Member, &membership.Admin)
+	if err == sql.ErrNoRows {
+		return Membership{}, nil
+	}
+	if err != nil {
+		return Membership{}, err
+	}
+	return membership, nil
+}
+
+// SetAdmin canvia el rol d'un membre. Retorna sql.ErrNoRows si l'usuari no és del grup.
+func (r *groupRepository) SetAdmin(ctx context.Context, groupID, userID uuid.UUID, isAdmin bool) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE group_members
+		SET is_admin = $3
+		WHERE group_id = $1 AND user_id = $2`,
+		groupID, userID, isAdmin,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *groupRepository) CountAdmins(ctx context.Context, groupID uuid.UUID) (int, error) {
+	var admins int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT user_id)
+		FROM group_members
+		WHERE group_id = $1 AND is_admin`,
+		groupID,
+	).Scan(&admins)
+	return admins, err
+}
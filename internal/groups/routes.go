@@ -1,14 +1,21 @@
 package groups
 
-import "github.com/gin-gonic/gin"
+import (
+	"orkestra-api/middleware"
 
-func RegisterRoutes(router *gin.RouterGroup, handler *GroupHandler) {
-	router.POST("/groups", handler.CreateGroup)
-	router.PUT("/groups/:id", handler.UpdateGroup)
-	router.DELETE("/groups/:id", handler.DeleteGroup)
-	router.GET("/groups/:id", handler.GetGroupByID)
-	router.GET("/groups", handler.GetAllGroups)
-	router.GET("/groups/user/:userID", handler.GetGroupsByUserID)
-	router.PUT("/groups/:id/users/:userID", handler.AddUserToGroup)
-	router.DELETE("/groups/:id/users/:userID", handler.RemoveUserFromGroup)
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *GroupHandler, authz *middleware.Authorizer) {
+	router.POST("/groups", authz.Require("groups:write"), handler.CreateGroup)
+	// Modificar o esborrar un grup, a més de groups:write, requereix ser administrador del grup
+	router.PUT("/groups/:id", authz.Require("groups:write"), handler.UpdateGroup)
+	router.DELETE("/groups/:id", authz.Require("groups:write"), handler.DeleteGroup)
+	router.GET("/groups/:id", authz.Require("groups:read"), handler.GetGroupByID)
+	router.GET("/groups", authz.Require("groups:read"), handler.GetAllGroups)
+	router.GET("/groups/user/:userID", authz.Require("groups:read"), handler.GetGroupsByUserID)
+	// Gestionar els membres, a més de groups:write, requereix ser administrador del grup
+	router.PUT("/groups/:id/users/:userID", authz.Require("groups:write"), handler.AddUserToGroup)
+	router.DELETE("/groups/:id/users/:userID", authz.Require("groups:write"), handler.RemoveUserFromGroup)
+	router.PUT("/groups/:id/users/:userID/admin", authz.Require("groups:write"), handler.SetGroupAdmin)
 }
\ No newline at end of file
@@ -2,21 +2,23 @@ package groups
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/google/uuid"
 )
 
 type GroupService interface {
-	Create(ctx context.Context, request GroupRequest) (Group, error)
-	Update(ctx context.Context, id string, request GroupRequest) (Group, error)
-	Delete(ctx context.Context, id string) error
+	Create(ctx context.Context, caller Caller, request GroupRequest) (Group, error)
+	Update(ctx context.Context, caller Caller, id string, request GroupRequest) (Group, error)
+	Delete(ctx context.Context, caller Caller, id string) error
 	FindByID(ctx context.Context, id string) (Group, error)
 	FindByName(ctx context.Context, name string) (Group, error)
 	FindAll(ctx context.Context) ([]Group, error)
 	FindByUserID(ctx context.Context, userID string) ([]Group, error)
-	AddUserToGroup(ctx context.Context, groupID, userID string) error
-	RemoveUserFromGroup(ctx context.Context, groupID, userID string) error
+	AddUserToGroup(ctx context.Context, caller Caller, groupID, userID string) error
+	RemoveUserFromGroup(ctx context.Context, caller Caller, groupID, userID string) error
+	SetAdmin(ctx context.Context, caller Caller, groupID, userID string, isAdmin bool) error
+	Membership(ctx context.Context, groupID, userID uuid.UUID) (Membership, error)
+	Authorize(ctx context.Context, caller Caller, groupID uuid.UUID, requireAdmin bool) error
 }
 
 type groupService struct {
@@ -27,7 +29,8 @@ func NewGroupService(repo GroupRepository) GroupService {
 	return &groupService{repo}
 }
 
-func (s *groupService) Create(ctx context.Context, request GroupRequest) (Group, error) {
+// Create crea el grup amb qui el crea com a administrador.
+func (s *groupService) Create(ctx context.Context, caller Caller, request GroupRequest) (Group, error) {
 	// Validate the request
 	if request.Name == "" {
 		return Group{}, ErrInvalidRequest
@@ -47,14 +50,25 @@ func (s *groupService) Create(ctx context.Context, request GroupRequest) (Group,
 		Name: request.Name,
 	}
 
-	return s.repo.Create(ctx, group)
+	group, err = s.repo.Create(ctx, group)
+	if err != nil {
+		return Group{}, err
+	}
+	if err := s.repo.AddUserToGroup(ctx, uuid.New(), group.ID, caller.UserID, true); err != nil {
+		return Group{}, err
+	}
+	return group, nil
 }
 
-func (s *groupService) Update(ctx context.Context, id string, request GroupRequest) (Group, error) {
+// Update canvia el nom del grup. Només ho pot fer un administrador del grup.
+func (s *groupService) Update(ctx context.Context, caller Caller, id string, request GroupRequest) (Group, error) {
 	groupID, err := uuid.Parse(id)
 	if err != nil {
 		return Group{}, ErrInvalidID
 	}
+	if err := s.Authorize(ctx, caller, groupID, true); err != nil {
+		return Group{}, err
+	}
 
 	// Validate the request
 	if request.Name == "" {
@@ -71,11 +85,15 @@ func (s *groupService) Update(ctx context.Context, id string, request GroupReque
 	return s.repo.Update(ctx, group)
 }
 
-func (s *groupService) Delete(ctx context.Context, id string) error {
+// Delete esborra el grup. Només ho pot fer un administrador del grup.
+func (s *groupService) Delete(ctx context.Context, caller Caller, id string) error {
 	groupID, err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidID
 	}
+	if err := s.Authorize(ctx, caller, groupID, true); err != nil {
+		return err
+	}
 
 	return s.repo.Delete(ctx, groupID)
 }
@@ -126,9 +144,8 @@ func (s *groupService) FindByUserID(ctx context.Context, userID string) ([]Group
 	return groups, nil
 }
 
-func (s *groupService) AddUserToGroup(ctx context.Context, groupID, userID string) error {
-	fmt.Println("AddUserToGroup")
-	fmt.Printf("groupID: %s, userID: %s\n", groupID, userID)
+// AddUserToGroup afegeix un membre. Si ja ho és no fa res.
+func (s *groupService) AddUserToGroup(ctx context.Context, caller Caller, groupID, userID string) error {
 	groupUUID, err := uuid.Parse(groupID)
 	if err != nil {
 		return ErrInvalidID
@@ -138,11 +155,22 @@ func (s *groupService) AddUserToGroup(ctx context.Context, groupID, userID strin
 	if err != nil {
 		return ErrInvalidID
 	}
+	if err := s.Authorize(ctx, caller, groupUUID, true); err != nil {
+		return err
+	}
+
+	membership, err := s.repo.FindMembership(ctx, groupUUID, userUUID)
+	if err != nil {
+		return err
+	}
+	if membership.Member {
+		return nil
+	}
 	id := uuid.New()
-	return s.repo.AddUserToGroup(ctx, id, groupUUID, userUUID)
+	return s.repo.AddUserToGroup(ctx, id, groupUUID, userUUID, false)
 }
 
-func (s *groupService) RemoveUserFromGroup(ctx context.Context, groupID, userID string) error {
+func (s *groupService) RemoveUserFromGroup(ctx context.Context, caller Caller, groupID, userID string) error {
 	groupUUID, err := uuid.Parse(groupID)
 	if err != nil {
 		return ErrInvalidID
@@ -152,6 +180,83 @@ func (s *groupService) RemoveUserFromGroup(ctx context.Context, groupID, userID
 	if err != nil {
 		return ErrInvalidID
 	}
+	if err := s.Authorize(ctx, caller, groupUUID, true); err != nil {
+		return err
+	}
+
+	membership, err := s.repo.FindMembership(ctx, groupUUID, userUUID)
+	if err != nil {
+		return err
+	}
+	if membership.Admin {
+		if err := s.ensureAnotherAdmin(ctx, groupUUID); err != nil {
+			return err
+		}
+	}
 
 	return s.repo.RemoveUserFromGroup(ctx, groupUUID, userUUID)
-}
\ No newline at end of file
+}
+
+// SetAdmin fa o desfà administrador del grup un membre.
+func (s *groupService) SetAdmin(ctx context.Context, caller Caller, groupID, userID string, isAdmin bool) error {
+	groupUUID, err := uuid.Parse(groupID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := s.Authorize(ctx, caller, groupUUID, true); err != nil {
+		return err
+	}
+
+	membership, err := s.repo.FindMembership(ctx, groupUUID, userUUID)
+	if err != nil {
+		return err
+	}
+	if !membership.Member {
+		return ErrUserNotInGroup
+	}
+	if membership.Admin && !isAdmin {
+		if err := s.ensureAnotherAdmin(ctx, groupUUID); err != nil {
+			return err
+		}
+	}
+	return s.repo.SetAdmin(ctx, groupUUID, userUUID, isAdmin)
+}
+
+func (s *groupService) Membership(ctx context.Context, groupID, userID uuid.UUID) (Membership, error) {
+	return s.repo.FindMembership(ctx, groupID, userID)
+}
+
+// Authorize comprova que el caller és membre del grup i, si requireAdmin, que n'és
+// administrador. Els perfils d'administrador sempre hi poden actuar.
+func (s *groupService) Authorize(ctx context.Context, caller Caller, groupID uuid.UUID, requireAdmin bool) error {
+	if caller.Admin {
+		return nil
+	}
+	membership, err := s.repo.FindMembership(ctx, groupID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	if !membership.Member {
+		return ErrNotGroupMember
+	}
+	if requireAdmin && !membership.Admin {
+		return ErrGroupAdminRequired
+	}
+	return nil
+}
+
+// ensureAnotherAdmin evita deixar un grup sense cap administrador.
+func (s *groupService) ensureAnotherAdmin(ctx context.Context, groupID uuid.UUID) error {
+	admins, err := s.repo.CountAdmins(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastGroupAdmin
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType és el tipus MIME dels fitxers .ics (RFC 5545).
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets és la llargada màxima d'una línia abans de plegar-la, sense el CRLF.
+const maxLineOctets = 75
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+)
+
+// Calendar és un VCALENDAR amb els esdeveniments a publicar.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Event és un VEVENT. Amb AllDay només es fan servir les dates de Start i End, i End
+// és l'últim dia inclòs (al fitxer s'escriu el dia següent, com demana l'RFC).
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+// Write escriu el calendari en format iCalendar.
+func Write(w io.Writer, calendar Calendar) error {
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw}
+	stamp := time.Now().UTC().Format(dateTimeLayout)
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", "-//Orkestra//Orkestra API//CA")
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if calendar.Name != "" {
+		e.line("X-WR-CALNAME", escapeText(calendar.Name))
+	}
+	for _, event := range calendar.Events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", escapeText(event.UID))
+		e.line("DTSTAMP", stamp)
+		if event.AllDay {
+			end := event.End
+			if end.Before(event.Start) {
+				end = event.Start
+			}
+			e.line("DTSTART;VALUE=DATE", event.Start.Format(dateLayout))
+			e.line("DTEND;VALUE=DATE", end.AddDate(0, 0, 1).Format(dateLayout))
+		} else {
+			e.line("DTSTART", event.Start.UTC().Format(dateTimeLayout))
+			if event.End.After(event.Start) {
+				e.line("DTEND", event.End.UTC().Format(dateTimeLayout))
+			}
+		}
+		e.line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			e.line("DESCRIPTION", escapeText(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			e.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		e.line("END", "VEVENT")
+	}
+	e.line("END", "VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// Render respon amb el calendari com a fitxer .ics.
+func Render(c *gin.Context, filename string, calendar Calendar) {
+	c.Header("Content-Type", ContentType)
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	if err := Write(c.Writer, calendar); err != nil {
+		c.Error(err)
+	}
+}
+
+// ParseTime interpreta les dates que arriben com a text dels repositoris: timestamps
+// RFC 3339 o dates soles.
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line escriu una propietat plegant-la a 75 octets sense partir cap caràcter UTF-8.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	content := name + ":" + value
+	var b strings.Builder
+	width := 0
+	for _, r := range content {
+		size := utf8.RuneLen(r)
+		if size < 0 {
+			size = len(string(utf8.RuneError))
+		}
+		if width+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	_, e.err = e.w.WriteString(b.String())
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeText escapa un valor de tipus TEXT (RFC 5545, 3.3.11).
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
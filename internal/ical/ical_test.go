@@ -0,0 +1,108 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Reunió", "Reunió"},
+		{"a;b,c", `a\;b\,c`},
+		{`C:\dades`, `C:\\dades`},
+		{"línia 1\nlínia 2", `línia 1\nlínia 2`},
+		{"windows\r\nmac\r", `windows\nmac\n`},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		lines int
+	}{
+		{name: "short", value: "Reunió", lines: 1},
+		{name: "exactly 75 octets", value: strings.Repeat("x", 75-len("SUMMARY:")), lines: 1},
+		{name: "76 octets", value: strings.Repeat("x", 76-len("SUMMARY:")), lines: 2},
+		{name: "multibyte at the boundary", value: strings.Repeat("x", 66) + strings.Repeat("à", 40), lines: 3},
+		{name: "emoji", value: strings.Repeat("📅", 60), lines: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			bw := bufio.NewWriter(&buf)
+			e := &encoder{w: bw}
+			e.line("SUMMARY", tt.value)
+			if e.err != nil {
+				t.Fatal(e.err)
+			}
+			bw.Flush()
+
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("line does not end with CRLF: %q", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(lines) != tt.lines {
+				t.Errorf("got %d lines, want %d", len(lines), tt.lines)
+			}
+			for i, line := range lines {
+				if len(line) > maxLineOctets {
+					t.Errorf("line %d has %d octets", i, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 character: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+			}
+			// Desplegar les línies ha de retornar el valor original
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != "SUMMARY:"+tt.value {
+				t.Errorf("unfolded = %q", unfolded)
+			}
+		})
+	}
+}
+
+func TestWriteAllDayEvent(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, Calendar{Name: "Tasques", Events: []Event{{
+		UID:        "task-1@orkestra",
+		Summary:    "Revisar pressupost, v2",
+		Categories: []string{"Alta", "Web;App"},
+		Start:      time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+		AllDay:     true,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Tasques\r\n",
+		"DTSTART;VALUE=DATE:20260302\r\n",
+		// L'últim dia inclòs és el 4, i DTEND és exclusiu
+		"DTEND;VALUE=DATE:20260305\r\n",
+		`SUMMARY:Revisar pressupost\, v2` + "\r\n",
+		`CATEGORIES:Alta,Web\;App` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
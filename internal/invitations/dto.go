@@ -0,0 +1,17 @@
+package invitations
+
+type InvitationRequest struct {
+	Email      string   `json:"email" binding:"required,email"`
+	ProfileID  string   `json:"profile_id" binding:"required"`
+	GroupIDs   []string `json:"group_ids"`
+	CustomerID string   `json:"customer_id"`
+}
+
+// AcceptInvitationRequest són les dades que tria el convidat. El correu és el de la invitació.
+type AcceptInvitationRequest struct {
+	Name        string `json:"name"`
+	Surname     string `json:"surname"`
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required,min=8"`
+}
@@ -0,0 +1,13 @@
+package invitations
+
+import "errors"
+
+var (
+	ErrInvalidID          = errors.New("invalid invitation ID")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvalidInvitation  = errors.New("invalid, expired or already used invitation")
+	ErrInvalidProfile     = errors.New("profile not found")
+	ErrInvalidGroup       = errors.New("group not found")
+	ErrInvalidCustomer    = errors.New("customer not found")
+	ErrAdminProfile       = errors.New("inviting to an admin profile requires profiles:admin")
+)
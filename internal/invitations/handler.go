@@ -0,0 +1,95 @@
+package invitations
+
+import (
+	"errors"
+	"net/http"
+	"orkestra-api/internal/users"
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationHandler struct {
+	service InvitationService
+}
+
+func NewInvitationHandler(service InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		service: service,
+	}
+}
+
+func (h *InvitationHandler) Create(c *gin.Context) {
+	var request InvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	canGrantAdmin := middleware.HasPermission(c, "profiles:admin")
+	invitation, err := h.service.Invite(c.Request.Context(), userID.(string), canGrantAdmin, request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, invitation)
+}
+
+func (h *InvitationHandler) GetPending(c *gin.Context) {
+	invitations, err := h.service.FindPending(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *InvitationHandler) Delete(c *gin.Context) {
+	if err := h.service.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *InvitationHandler) GetByToken(c *gin.Context) {
+	invitation, err := h.service.FindByToken(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, invitation)
+}
+
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	var request AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, err := h.service.Accept(c.Request.Context(), c.Param("token"), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidProfile), errors.Is(err, ErrInvalidGroup), errors.Is(err, ErrInvalidCustomer):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrAdminProfile):
+		return http.StatusForbidden
+	case errors.Is(err, ErrInvitationNotFound), errors.Is(err, ErrInvalidInvitation):
+		return http.StatusNotFound
+	case errors.Is(err, users.ErrEmailTaken), errors.Is(err, users.ErrUsernameTaken), errors.Is(err, users.ErrPhoneNumberTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
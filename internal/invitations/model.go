@@ -0,0 +1,29 @@
+package invitations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation convida un correu a crear-se un compte amb un perfil, uns grups i,
+// opcionalment, un client. Només se'n desa el hash del token.
+type Invitation struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	Email          string      `json:"email" db:"email"`
+	ProfileID      uuid.UUID   `json:"profile_id" db:"profile_id"`
+	CustomerID     *uuid.UUID  `json:"customer_id" db:"customer_id"`
+	GroupIDs       []uuid.UUID `json:"group_ids" db:"group_ids"`
+	TokenHash      string      `json:"-" db:"token_hash"`
+	InvitedBy      *uuid.UUID  `json:"invited_by" db:"invited_by"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	ExpiresAt      time.Time   `json:"expires_at" db:"expires_at"`
+	AcceptedAt     *time.Time  `json:"accepted_at,omitempty" db:"accepted_at"`
+	AcceptedUserID *uuid.UUID  `json:"accepted_user_id,omitempty" db:"accepted_user_id"`
+}
+
+// PublicInvitation és el que pot veure qui té el token, abans d'acceptar-la.
+type PublicInvitation struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,202 @@
+package invitations
+
+import (
+	"context"
+	"database/sql"
+	"orkestra-api/internal/users"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation Invitation) (Invitation, error)
+	FindPending(ctx context.Context) ([]Invitation, error)
+	FindPendingByTokenHash(ctx context.Context, tokenHash string) (Invitation, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	Accept(ctx context.Context, tokenHash string, user users.User) (Invitation, error)
+}
+
+type invitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// Create desa la invitació i revoca les pendents del mateix correu, perquè només en
+// funcioni l'última.
+func (r *invitationRepository) Create(ctx context.Context, invitation Invitation) (Invitation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Invitation{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE invitations
+		SET revoked_at = NOW()
+		WHERE lower(email) = lower($1) AND accepted_at IS NULL AND revoked_at IS NULL`,
+		invitation.Email,
+	); err != nil {
+		return Invitation{}, err
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO invitations (id, email, profile_id, customer_id, group_ids, token_hash, invited_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), $8)
+		RETURNING created_at`,
+		invitation.ID, invitation.Email, invitation.ProfileID, invitation.CustomerID, pq.Array(uuidStrings(invitation.GroupIDs)),
+		invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt,
+	).Scan(&invitation.CreatedAt)
+	if err != nil {
+		return Invitation{}, err
+	}
+	return invitation, tx.Commit()
+}
+
+func (r *invitationRepository) FindPending(ctx context.Context) ([]Invitation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, email, profile_id, customer_id, group_ids, invited_by, created_at, expires_at
+		FROM invitations
+		WHERE accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *invitationRepository) FindPendingByTokenHash(ctx context.Context, tokenHash string) (Invitation, error) {
+	return scanInvitation(r.db.QueryRowContext(ctx, `
+		SELECT id, email, profile_id, customer_id, group_ids, invited_by, created_at, expires_at
+		FROM invitations
+		WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`,
+		tokenHash,
+	))
+}
+
+func (r *invitationRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE invitations
+		SET revoked_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Accept gasta la invitació i crea l'usuari amb el perfil, els grups i el client de la
+// invitació, tot en una transacció. Retorna sql.ErrNoRows si la invitació ja no és vàlida.
+func (r *invitationRepository) Accept(ctx context.Context, tokenHash string, user users.User) (Invitation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Invitation{}, err
+	}
+	defer tx.Rollback()
+
+	// L'UPDATE bloqueja la fila: dues acceptacions alhora no poden crear dos usuaris
+	invitation, err := scanInvitation(tx.QueryRowContext(ctx, `
+		UPDATE invitations
+		SET accepted_at = NOW()
+		WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING id, email, profile_id, customer_id, group_ids, invited_by, created_at, expires_at`,
+		tokenHash,
+	))
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO users (id, name, surname, phone_number, email, username, password, is_verified, is_active, profile_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE, TRUE, $8)`,
+		user.ID, user.Name, user.Surname, user.PhoneNumber, invitation.Email, user.Username, user.Password, invitation.ProfileID,
+	); err != nil {
+		return Invitation{}, err
+	}
+	for _, groupID := range invitation.GroupIDs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO group_members (id, group_id, user_id, joined_at)
+			VALUES ($1, $2, $3, NOW())`,
+			uuid.New(), groupID, user.ID,
+		); err != nil {
+			return Invitation{}, err
+		}
+	}
+	if invitation.CustomerID != nil {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO customer_users (id, customer_id, user_id)
+			VALUES ($1, $2, $3)`,
+			uuid.New(), *invitation.CustomerID, user.ID,
+		); err != nil {
+			return Invitation{}, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE invitations
+		SET accepted_user_id = $2
+		WHERE id = $1`,
+		invitation.ID, user.ID,
+	); err != nil {
+		return Invitation{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Invitation{}, err
+	}
+	invitation.AcceptedUserID = &user.ID
+	return invitation, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInvitation(row rowScanner) (Invitation, error) {
+	var invitation Invitation
+	var groupIDs []string
+	err := row.Scan(&invitation.ID, &invitation.Email, &invitation.ProfileID, &invitation.CustomerID, pq.Array(&groupIDs),
+		&invitation.InvitedBy, &invitation.CreatedAt, &invitation.ExpiresAt)
+	if err != nil {
+		return Invitation{}, err
+	}
+	invitation.GroupIDs = make([]uuid.UUID, 0, len(groupIDs))
+	for _, id := range groupIDs {
+		groupID, err := uuid.Parse(id)
+		if err != nil {
+			return Invitation{}, err
+		}
+		invitation.GroupIDs = append(invitation.GroupIDs, groupID)
+	}
+	return invitation, nil
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
@@ -0,0 +1,22 @@
+package invitations
+
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *InvitationHandler, authz *middleware.Authorizer) {
+	invitations := router.Group("/invitations")
+	{
+		invitations.POST("", authz.Require("users:write"), handler.Create)
+		invitations.GET("", authz.Require("users:write"), handler.GetPending)
+		invitations.DELETE("/:id", authz.Require("users:write"), handler.Delete)
+	}
+}
+
+// RegisterPublicRoutes registra les rutes del convidat, que encara no té compte.
+func RegisterPublicRoutes(router *gin.RouterGroup, handler *InvitationHandler) {
+	router.GET("/invitations/:token", handler.GetByToken)
+	router.POST("/invitations/:token/accept", handler.Accept)
+}
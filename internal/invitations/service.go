@@ -0,0 +1,244 @@
+package invitations
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"orkestra-api/config"
+	"orkestra-api/internal/customers"
+	"orkestra-api/internal/groups"
+	"orkestra-api/internal/mailer"
+	"orkestra-api/internal/profiles"
+	"orkestra-api/internal/users"
+	"orkestra-api/internal/usertokens"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type InvitationService interface {
+	Invite(ctx context.Context, invitedBy string, canGrantAdmin bool, request InvitationRequest) (Invitation, error)
+	FindPending(ctx context.Context) ([]Invitation, error)
+	Revoke(ctx context.Context, id string) error
+	FindByToken(ctx context.Context, token string) (PublicInvitation, error)
+	Accept(ctx context.Context, token string, request AcceptInvitationRequest) (users.UserResponse, error)
+}
+
+type invitationService struct {
+	repo            InvitationRepository
+	userRepo        users.UserRepository
+	profileService  profiles.ProfileService
+	groupService    groups.GroupService
+	customerService customers.CustomerService
+	mailer          mailer.Mailer
+	cfg             config.Config
+}
+
+func NewInvitationService(repo InvitationRepository, userRepo users.UserRepository, profileService profiles.ProfileService, groupService groups.GroupService, customerService customers.CustomerService, mailer mailer.Mailer, cfg config.Config) InvitationService {
+	return &invitationService{
+		repo:            repo,
+		userRepo:        userRepo,
+		profileService:  profileService,
+		groupService:    groupService,
+		customerService: customerService,
+		mailer:          mailer,
+		cfg:             cfg,
+	}
+}
+
+// Invite crea la invitació i l'envia per correu. Només qui té profiles:admin pot convidar
+// a un perfil d'administrador.
+func (s *invitationService) Invite(ctx context.Context, invitedBy string, canGrantAdmin bool, request InvitationRequest) (Invitation, error) {
+	email := strings.TrimSpace(request.Email)
+
+	profile, err := s.profileService.FindByID(ctx, request.ProfileID)
+	if errors.Is(err, profiles.ErrInvalidProfileID) || errors.Is(err, profiles.ErrProfileNotFound) {
+		return Invitation{}, ErrInvalidProfile
+	}
+	if err != nil {
+		return Invitation{}, err
+	}
+	if profile.IsAdmin && !canGrantAdmin {
+		return Invitation{}, ErrAdminProfile
+	}
+	profileID, err := uuid.Parse(profile.ID)
+	if err != nil {
+		return Invitation{}, ErrInvalidProfile
+	}
+
+	groupIDs := []uuid.UUID{}
+	seen := make(map[uuid.UUID]struct{})
+	for _, id := range request.GroupIDs {
+		group, err := s.groupService.FindByID(ctx, id)
+		if errors.Is(err, groups.ErrInvalidID) || errors.Is(err, sql.ErrNoRows) {
+			return Invitation{}, ErrInvalidGroup
+		}
+		if err != nil {
+			return Invitation{}, err
+		}
+		if _, ok := seen[group.ID]; ok {
+			continue
+		}
+		seen[group.ID] = struct{}{}
+		groupIDs = append(groupIDs, group.ID)
+	}
+
+	var customerID *uuid.UUID
+	if request.CustomerID != "" {
+		customer, err := s.customerService.FindByID(ctx, request.CustomerID)
+		if errors.Is(err, customers.ErrInvalidID) || errors.Is(err, sql.ErrNoRows) {
+			return Invitation{}, ErrInvalidCustomer
+		}
+		if err != nil {
+			return Invitation{}, err
+		}
+		customerID = &customer.ID
+	}
+
+	// Un usuari desactivat també ocupa el correu
+	_, err = s.userRepo.FindByEmail(ctx, email)
+	if err == nil || errors.Is(err, users.ErrInactiveUser) {
+		return Invitation{}, users.ErrEmailTaken
+	}
+	if !errors.Is(err, users.ErrUserNotFound) {
+		return Invitation{}, err
+	}
+
+	var inviter *uuid.UUID
+	if inviterID, err := uuid.Parse(invitedBy); err == nil {
+		inviter = &inviterID
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Invitation{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	ttl := time.Duration(s.cfg.InvitationTTLHours) * time.Hour
+
+	invitation, err := s.repo.Create(ctx, Invitation{
+		ID:         uuid.New(),
+		Email:      email,
+		ProfileID:  profileID,
+		CustomerID: customerID,
+		GroupIDs:   groupIDs,
+		TokenHash:  usertokens.HashToken(token),
+		InvitedBy:  inviter,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	// Sense el correu el convidat no té el token: l'administrador ha de tornar a convidar
+	link := s.cfg.AppURL + "/invitations/accept?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "T'han convidat a Orkestra",
+		Body: fmt.Sprintf("Hola,\n\nT'han convidat a Orkestra. Per crear el teu compte obre aquest enllaç:\n\n%s\n\nL'enllaç caduca d'aquí a %d hores.\n",
+			link, s.cfg.InvitationTTLHours),
+	})
+	if err != nil {
+		return Invitation{}, fmt.Errorf("error sending invitation email: %w", err)
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) FindPending(ctx context.Context) ([]Invitation, error) {
+	return s.repo.FindPending(ctx)
+}
+
+func (s *invitationService) Revoke(ctx context.Context, id string) error {
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	err = s.repo.Revoke(ctx, invitationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvitationNotFound
+	}
+	return err
+}
+
+// FindByToken retorna el correu de la invitació perquè el frontend el mostri al formulari.
+func (s *invitationService) FindByToken(ctx context.Context, token string) (PublicInvitation, error) {
+	invitation, err := s.repo.FindPendingByTokenHash(ctx, usertokens.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return PublicInvitation{}, ErrInvalidInvitation
+	}
+	if err != nil {
+		return PublicInvitation{}, err
+	}
+	return PublicInvitation{Email: invitation.Email, ExpiresAt: invitation.ExpiresAt}, nil
+}
+
+// Accept crea l'usuari de la invitació. El correu queda verificat perquè el token hi ha arribat.
+func (s *invitationService) Accept(ctx context.Context, token string, request AcceptInvitationRequest) (users.UserResponse, error) {
+	_, err := s.userRepo.FindByUsername(ctx, request.Username)
+	if err == nil || errors.Is(err, users.ErrInactiveUser) {
+		return users.UserResponse{}, users.ErrUsernameTaken
+	}
+	if !errors.Is(err, users.ErrUserNotFound) {
+		return users.UserResponse{}, err
+	}
+	_, err = s.userRepo.FindByPhoneNumber(ctx, request.PhoneNumber)
+	if err == nil || errors.Is(err, users.ErrInactiveUser) {
+		return users.UserResponse{}, users.ErrPhoneNumberTaken
+	}
+	if !errors.Is(err, users.ErrUserNotFound) {
+		return users.UserResponse{}, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return users.UserResponse{}, err
+	}
+	user := users.User{
+		ID:          uuid.New(),
+		Name:        request.Name,
+		Surname:     request.Surname,
+		PhoneNumber: request.PhoneNumber,
+		Username:    request.Username,
+		Password:    string(hashedPassword),
+		IsVerified:  true,
+		IsActive:    true,
+	}
+
+	invitation, err := s.repo.Accept(ctx, usertokens.HashToken(token), user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return users.UserResponse{}, ErrInvalidInvitation
+	}
+	if err != nil {
+		return users.UserResponse{}, uniqueViolation(err)
+	}
+
+	now := time.Now()
+	user.Email = invitation.Email
+	user.ProfileID = invitation.ProfileID
+	user.CreatedAt = now
+	user.PasswordChangedAt = &now
+	return users.MapUserToResponse(user), nil
+}
+
+// uniqueViolation tradueix les claus úniques de users que algú ha ocupat entre la
+// comprovació i la transacció.
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return err
+	}
+	switch {
+	case strings.Contains(pqErr.Constraint, "phone"):
+		return users.ErrPhoneNumberTaken
+	case strings.Contains(pqErr.Constraint, "email"):
+		return users.ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Etapes del processament d'una pregunta que es cronometren a llm_queries.
+const (
+	stageClassify      = "classify"
+	stageSQLGeneration = "sql_generation"
+	stageExecution     = "execution"
+	stageAnswer        = "answer"
+)
+
+// queryTrace acumula el que passa durant una pregunta (temps per etapa, tokens, SQL...)
+// per desar-ho a llm_queries en acabar. Viatja dins del context perquè callLLM hi pugui
+// sumar els tokens sense canviar totes les signatures.
+type queryTrace struct {
+	mu               sync.Mutex
+	start            time.Time
+	stages           map[string]time.Duration
+	mode             string
+	cache            string
+	sqlQuery         string
+	rowCount         int
+	promptTokens     int
+	completionTokens int
+	calls            int
+}
+
+type traceKey struct{}
+
+func newQueryTrace() *queryTrace {
+	return &queryTrace{start: time.Now(), stages: make(map[string]time.Duration)}
+}
+
+func withTrace(ctx context.Context, trace *queryTrace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// traceFrom retorna la traça del context, o nil si no n'hi ha. Tots els mètodes
+// accepten un receptor nil.
+func traceFrom(ctx context.Context) *queryTrace {
+	trace, _ := ctx.Value(traceKey{}).(*queryTrace)
+	return trace
+}
+
+func (t *queryTrace) addUsage(completion Completion) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls++
+	t.promptTokens += completion.PromptTokens
+	t.completionTokens += completion.CompletionTokens
+}
+
+// stage suma a l'etapa el temps transcorregut des de start.
+func (t *queryTrace) stage(name string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stages[name] += time.Since(start)
+}
+
+func (t *queryTrace) setMode(mode string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mode = mode
+}
+
+func (t *queryTrace) setCache(status string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cache = status
+}
+
+// responseCache retorna l'estat de memòria cau d'una resposta que pot ser nil.
+func responseCache(response *QueryResponse) string {
+	if response == nil {
+		return ""
+	}
+	return response.Cache
+}
+
+func (t *queryTrace) setResult(sqlQuery string, rowCount int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sqlQuery = sqlQuery
+	t.rowCount = rowCount
+}
+
+func (t *queryTrace) milliseconds(name string) int {
+	return int(t.stages[name] / time.Millisecond)
+}
+
+// checkQuota rebutja la pregunta si l'usuari ja ha esgotat la quota diària de consultes o de tokens.
+func (s *Service) checkQuota(ctx context.Context, userID uuid.UUID) error {
+	if s.cfg.LLMDailyQueryQuota <= 0 && s.cfg.LLMDailyTokenQuota <= 0 {
+		return nil
+	}
+	usage, err := s.repo.UsageToday(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.cfg.LLMDailyQueryQuota > 0 && usage.Queries >= s.cfg.LLMDailyQueryQuota {
+		return ErrQuotaExceeded
+	}
+	if s.cfg.LLMDailyTokenQuota > 0 && usage.Tokens >= s.cfg.LLMDailyTokenQuota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// recordQuery desa la pregunta a llm_queries. Un error aquí no ha de fer fallar la resposta.
+func (s *Service) recordQuery(ctx context.Context, req QueryRequest, trace *queryTrace, queryErr error) uuid.UUID {
+	trace.mu.Lock()
+	entry := QueryLog{
+		ID:               uuid.New(),
+		UserID:           req.UserID,
+		Question:         req.Question,
+		Mode:             trace.mode,
+		CacheStatus:      trace.cache,
+		Provider:         s.provider.Name(),
+		Model:            s.provider.Model(),
+		SQLQuery:         trace.sqlQuery,
+		RowCount:         trace.rowCount,
+		ClassifyMs:       trace.milliseconds(stageClassify),
+		SQLGenerationMs:  trace.milliseconds(stageSQLGeneration),
+		ExecutionMs:      trace.milliseconds(stageExecution),
+		AnswerMs:         trace.milliseconds(stageAnswer),
+		TotalMs:          int(time.Since(trace.start) / time.Millisecond),
+		PromptTokens:     trace.promptTokens,
+		CompletionTokens: trace.completionTokens,
+		LLMCalls:         trace.calls,
+	}
+	trace.mu.Unlock()
+	if req.ConversationID != uuid.Nil {
+		conversationID := req.ConversationID
+		entry.ConversationID = &conversationID
+	}
+	if queryErr != nil {
+		entry.Error = queryErr.Error()
+	}
+
+	// La pregunta s'ha de registrar encara que el client hagi tancat la connexió
+	if err := s.repo.CreateQueryLog(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("llm audit: error recording query %s: %v", entry.ID, err)
+		return uuid.Nil
+	}
+	return entry.ID
+}
+
+func (s *Service) FindHistory(ctx context.Context, userID string, limit, offset string) ([]QueryLog, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	l, err := strconv.Atoi(limit)
+	if err != nil || l <= 0 {
+		l = 50
+	}
+	if l > 200 {
+		l = 200
+	}
+	o, err := strconv.Atoi(offset)
+	if err != nil || o < 0 {
+		o = 0
+	}
+	return s.repo.FindQueryLogsByUserID(ctx, userUUID, l, o)
+}
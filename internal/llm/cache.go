@@ -0,0 +1,182 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Estat de la memòria cau que es retorna a QueryResponse.
+const (
+	CacheMiss      = "miss"
+	CacheSQLHit    = "sql_hit"
+	CacheAnswerHit = "answer_hit"
+	CacheBypass    = "bypass"
+)
+
+// CachedQuery és una pregunta ja resolta: la consulta que la respon i, opcionalment,
+// l'última resposta mentre no caduqui.
+type CachedQuery struct {
+	Key             string
+	Question        string
+	Scope           string
+	SchemaVersion   string
+	SQLQuery        string
+	Answer          string
+	AnswerExpiresAt *time.Time
+	Hits            int
+}
+
+// schemaVersion és una empremta de l'esquema carregat: si canvia, les consultes desades deixen de servir.
+func schemaVersion(schema *QueryContext) string {
+	raw, _ := json.Marshal(schema)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// normaliseQuestion fa que variacions trivials de la mateixa pregunta comparteixin entrada:
+// majúscules, espais repetits i puntuació final.
+func normaliseQuestion(question string) string {
+	fields := strings.Fields(strings.ToLower(question))
+	normalised := strings.Join(fields, " ")
+	return strings.TrimRightFunc(normalised, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+}
+
+func (d dataScope) cacheScope() string {
+	if d.restricted() {
+		return "customer:" + d.customerID.String()
+	}
+	if !d.readCost {
+		return "all:no-cost"
+	}
+	return "all"
+}
+
+// cacheKey retorna la clau de la pregunta i si es pot fer servir la memòria cau.
+// Les preguntes de seguiment depenen de la conversa i les estratègiques de dades
+// que canvien, així que no es desen.
+func (s *Service) cacheKey(req QueryRequest, scope dataScope) (string, bool) {
+	if !s.cfg.LLMCacheEnabled || len(req.History) > 0 || req.Mode == ModeStrategic {
+		return "", false
+	}
+	raw := strings.Join([]string{normaliseQuestion(req.Question), s.schemaVersion, scope.cacheScope()}, "\x00")
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// answerFromCache respon la pregunta amb la consulta desada. Retorna nil sense error si no hi
+// ha entrada o si la consulta desada ja no funciona, i llavors es fa el flux complet.
+func (s *Service) answerFromCache(ctx context.Context, req QueryRequest, key string, scope dataScope, emit StreamEmitter) (*QueryResponse, error) {
+	entry, err := s.repo.FindCachedQuery(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("llm cache: error reading entry: %v", err)
+		return nil, nil
+	}
+
+	schema, denied, err := s.schemaFor(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := traceFrom(ctx)
+	trace.setMode(ModeSQL)
+	if err := emit.send(EventSQLGenerated, map[string]interface{}{"query": entry.SQLQuery, "cached": true}); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	sqlQuery, sqlData, truncated, err := s.executeQuery(ctx, entry.SQLQuery, schema, denied, scope)
+	trace.stage(stageExecution, start)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("llm cache: cached query no longer runs, discarding it: %v", err)
+		if err := s.repo.DeleteCachedQuery(ctx, key); err != nil {
+			log.Printf("llm cache: error discarding entry: %v", err)
+		}
+		return nil, nil
+	}
+	trace.setResult(sqlQuery, len(sqlData))
+	if err := emit.send(EventRows, map[string]interface{}{
+		"query":     sqlQuery,
+		"count":     len(sqlData),
+		"data":      sqlData,
+		"truncated": truncated,
+	}); err != nil {
+		return nil, err
+	}
+
+	status := CacheSQLHit
+	answer := entry.Answer
+	if answer != "" && entry.AnswerExpiresAt != nil && time.Now().Before(*entry.AnswerExpiresAt) {
+		status = CacheAnswerHit
+		if err := emit.send(EventToken, map[string]interface{}{"text": answer}); err != nil {
+			return nil, err
+		}
+	} else {
+		start = time.Now()
+		answer, err = s.generateAnswer(ctx, req.Question, sqlQuery, sqlData, emit)
+		trace.stage(stageAnswer, start)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if status == CacheAnswerHit {
+		err = s.repo.TouchCachedQuery(ctx, key)
+	} else {
+		err = s.repo.UpdateCachedAnswer(ctx, key, answer, s.answerExpiry())
+	}
+	if err != nil {
+		log.Printf("llm cache: error updating entry: %v", err)
+	}
+
+	return &QueryResponse{
+		Answer:    answer,
+		Mode:      ModeSQL,
+		Query:     sqlQuery,
+		Data:      sqlData,
+		Truncated: truncated,
+		Cache:     status,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// storeInCache desa la consulta que ha funcionat i, si hi ha TTL, la resposta.
+func (s *Service) storeInCache(ctx context.Context, key string, req QueryRequest, scope dataScope, response *QueryResponse) {
+	entry := CachedQuery{
+		Key:           key,
+		Question:      normaliseQuestion(req.Question),
+		Scope:         scope.cacheScope(),
+		SchemaVersion: s.schemaVersion,
+		SQLQuery:      response.Query,
+	}
+	if expiresAt := s.answerExpiry(); expiresAt != nil {
+		entry.Answer = response.Answer
+		entry.AnswerExpiresAt = expiresAt
+	}
+	if err := s.repo.SaveCachedQuery(ctx, entry); err != nil {
+		log.Printf("llm cache: error saving entry: %v", err)
+	}
+}
+
+func (s *Service) answerExpiry() *time.Time {
+	if s.cfg.LLMCacheAnswerTTLSeconds <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(time.Duration(s.cfg.LLMCacheAnswerTTLSeconds) * time.Second)
+	return &expiresAt
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTitleLength   = 80
+	maxSummaryRows   = 3
+	maxSummaryLength = 800
+)
+
+func (s *Service) CreateConversation(ctx context.Context, userID string, request ConversationRequest) (Conversation, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return Conversation{}, ErrInvalidID
+	}
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		title = "Nova conversa"
+	}
+	return s.repo.CreateConversation(ctx, Conversation{
+		ID:     uuid.New(),
+		UserID: userUUID,
+		Title:  truncateText(title, maxTitleLength),
+	})
+}
+
+func (s *Service) FindConversationsByUserID(ctx context.Context, userID string) ([]Conversation, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return s.repo.FindConversationsByUserID(ctx, userUUID)
+}
+
+// FindConversation retorna la conversa amb tots els seus torns, només si pertany a l'usuari.
+func (s *Service) FindConversation(ctx context.Context, userID, id string) (Conversation, error) {
+	conversation, err := s.ownedConversation(ctx, userID, id)
+	if err != nil {
+		return Conversation{}, err
+	}
+	turns, err := s.repo.FindTurns(ctx, conversation.ID, 0)
+	if err != nil {
+		return Conversation{}, err
+	}
+	conversation.Turns = turns
+	return conversation, nil
+}
+
+func (s *Service) DeleteConversation(ctx context.Context, userID, id string) error {
+	conversation, err := s.ownedConversation(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteConversation(ctx, conversation.ID)
+}
+
+// SendMessage respon una nova pregunta dins d'una conversa, passant al model els últims
+// torns perquè pugui resoldre preguntes de seguiment, i desa el torn resultant.
+func (s *Service) SendMessage(ctx context.Context, userID, id string, request MessageRequest, readCost bool) (MessageResponse, error) {
+	conversation, err := s.ownedConversation(ctx, userID, id)
+	if err != nil {
+		return MessageResponse{}, err
+	}
+	history, err := s.repo.FindTurns(ctx, conversation.ID, s.cfg.LLMConversationHistory)
+	if err != nil {
+		return MessageResponse{}, err
+	}
+
+	response, err := s.ProcessQuery(ctx, QueryRequest{
+		Question:       request.Question,
+		UserID:         conversation.UserID,
+		History:        history,
+		ConversationID: conversation.ID,
+		ReadCost:       readCost,
+	})
+	if err != nil {
+		return MessageResponse{}, err
+	}
+
+	turn, err := s.repo.AddTurn(ctx, ConversationTurn{
+		ID:             uuid.New(),
+		ConversationID: conversation.ID,
+		Question:       request.Question,
+		SQLQuery:       response.Query,
+		ResultSummary:  summariseResult(response.Data, response.Truncated),
+		Answer:         response.Answer,
+	})
+	if err != nil {
+		return MessageResponse{}, err
+	}
+
+	return MessageResponse{Turn: turn, Response: response}, nil
+}
+
+func (s *Service) ownedConversation(ctx context.Context, userID, id string) (Conversation, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return Conversation{}, ErrInvalidID
+	}
+	conversationID, err := uuid.Parse(id)
+	if err != nil {
+		return Conversation{}, ErrInvalidID
+	}
+	conversation, err := s.repo.FindConversationByID(ctx, conversationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Conversation{}, ErrConversationNotFound
+	}
+	if err != nil {
+		return Conversation{}, err
+	}
+	// Una conversa d'un altre usuari es tracta com si no existís
+	if conversation.UserID != userUUID {
+		return Conversation{}, ErrConversationNotFound
+	}
+	return conversation, nil
+}
+
+// summariseResult resumeix les files retornades en un text curt que es desa amb el torn
+// i es reenvia al model a les preguntes següents, sense haver de guardar totes les dades.
+func summariseResult(data interface{}, truncated bool) string {
+	rows, ok := data.([]map[string]interface{})
+	if !ok || len(rows) == 0 {
+		return "0 files"
+	}
+
+	columns := make([]string, 0, len(rows[0]))
+	for col := range rows[0] {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	count := fmt.Sprintf("%d files", len(rows))
+	if truncated {
+		count = fmt.Sprintf("més de %d files", len(rows))
+	}
+
+	sample := rows
+	if len(sample) > maxSummaryRows {
+		sample = sample[:maxSummaryRows]
+	}
+	sampleJSON, _ := json.Marshal(sample)
+
+	summary := fmt.Sprintf("%s; columnes: %s; primeres files: %s", count, strings.Join(columns, ", "), string(sampleJSON))
+	return truncateText(summary, maxSummaryLength)
+}
+
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
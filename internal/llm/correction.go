@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// generateAndExecute genera la consulta i l'executa. Si falla la validació o Postgres
+// retorna un error, torna a demanar la consulta al model amb l'error, fins a
+// LLMSQLMaxAttempts intents. Retorna la consulta que ha funcionat, les files i tots els intents.
+func (s *Service) generateAndExecute(ctx context.Context, req QueryRequest, schema *QueryContext, denied map[string]struct{}, scope dataScope, emit StreamEmitter) (string, []map[string]interface{}, bool, []QueryAttempt, error) {
+	trace := traceFrom(ctx)
+	maxAttempts := s.cfg.LLMSQLMaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var attempts []QueryAttempt
+	for n := 1; n <= maxAttempts; n++ {
+		start := time.Now()
+		sqlQuery, err := s.generateSQLQuery(ctx, req.Question, schema, req.History, attempts)
+		trace.stage(stageSQLGeneration, start)
+		if err != nil {
+			return "", nil, false, attempts, fmt.Errorf("error generating SQL query: %w", err)
+		}
+		trace.setResult(sqlQuery, 0)
+		if err := emit.send(EventSQLGenerated, map[string]interface{}{"attempt": n, "query": sqlQuery}); err != nil {
+			return "", nil, false, attempts, err
+		}
+
+		start = time.Now()
+		safeQuery, data, truncated, err := s.executeQuery(ctx, sqlQuery, schema, denied, scope)
+		trace.stage(stageExecution, start)
+		trace.setResult(safeQuery, len(data))
+		if err == nil {
+			attempts = append(attempts, QueryAttempt{Attempt: n, Query: safeQuery, Rows: len(data)})
+			return safeQuery, data, truncated, attempts, nil
+		}
+
+		// Si la petició s'ha cancel·lat no té sentit tornar-ho a provar
+		if ctx.Err() != nil {
+			return "", nil, false, attempts, ctx.Err()
+		}
+		attempt := QueryAttempt{Attempt: n, Query: safeQuery, Error: describeSQLError(err)}
+		attempts = append(attempts, attempt)
+		if err := emit.send(EventSQLError, attempt); err != nil {
+			return "", nil, false, attempts, err
+		}
+		if n == maxAttempts {
+			return "", nil, false, attempts, &QueryAttemptsError{Attempts: attempts, Err: err}
+		}
+	}
+	return "", nil, false, attempts, nil
+}
+
+// describeSQLError resumeix l'error en el text que es reenvia al model, incloent-hi
+// el detall i la pista de Postgres quan n'hi ha.
+func describeSQLError(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		parts := []string{pqErr.Message}
+		if pqErr.Detail != "" {
+			parts = append(parts, "Detall: "+pqErr.Detail)
+		}
+		if pqErr.Hint != "" {
+			parts = append(parts, "Pista: "+pqErr.Hint)
+		}
+		return strings.Join(parts, ". ")
+	}
+	return err.Error()
+}
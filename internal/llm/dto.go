@@ -0,0 +1,14 @@
+package llm
+
+type ConversationRequest struct {
+	Title string `json:"title"`
+}
+
+type MessageRequest struct {
+	Question string `json:"question" binding:"required"`
+}
+
+type MessageResponse struct {
+	Turn     ConversationTurn `json:"turn"`
+	Response *QueryResponse   `json:"response"`
+}
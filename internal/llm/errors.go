@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidID            = errors.New("invalid ID")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrQuotaExceeded        = errors.New("daily LLM quota exceeded")
+	ErrStrategicNotAllowed  = errors.New("strategic mode requires the operators:read_cost permission")
+	ErrStrategicDisabled    = errors.New("strategic mode is disabled")
+)
+
+// UnsafeQueryError indica que la consulta generada pel model no ha passat la validació
+// de només lectura i no s'ha arribat a executar.
+type UnsafeQueryError struct {
+	Reason string
+}
+
+func (e *UnsafeQueryError) Error() string {
+	return fmt.Sprintf("unsafe query: %s", e.Reason)
+}
+
+func unsafeQuery(reason string) error {
+	return &UnsafeQueryError{Reason: reason}
+}
+
+// QueryAttemptsError indica que cap de les consultes generades s'ha pogut executar.
+// Conté tots els intents per poder depurar el prompt.
+type QueryAttemptsError struct {
+	Attempts []QueryAttempt
+	Err      error
+}
+
+func (e *QueryAttemptsError) Error() string {
+	return fmt.Sprintf("error executing query after %d attempts: %v", len(e.Attempts), e.Err)
+}
+
+func (e *QueryAttemptsError) Unwrap() error {
+	return e.Err
+}
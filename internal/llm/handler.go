@@ -1,7 +1,10 @@
 package llm
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"orkestra-api/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -24,24 +27,77 @@ func (h *Handler) Query(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from JWT token if available
-	if userID, exists := c.Get("userID"); exists {
-		if uid, ok := userID.(uuid.UUID); ok {
-			req.UserID = uid
-		}
+	// L'usuari surt sempre del token: l'abast de les dades depèn d'ell
+	uid, ok := callerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
 	}
+	req.UserID = uid
+	req.ReadCost = middleware.HasPermission(c, costPermission)
 
 	response, err := h.service.ProcessQuery(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), errorBody(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// QueryStream respon la pregunta com a Server-Sent Events: sql_generated, rows,
+// un token per cada fragment de la resposta i finalment done (o error).
+func (h *Handler) QueryStream(c *gin.Context) {
+	var req QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid, ok := callerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	req.UserID = uid
+	req.ReadCost = middleware.HasPermission(c, costPermission)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+	emit := func(event string, data interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+		return nil
+	}
+
+	response, err := h.service.ProcessQueryStream(ctx, req, emit)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		body := errorBody(err)
+		body["status"] = errorStatus(err)
+		emit(EventError, body)
+		return
+	}
+	emit(EventDone, response)
+}
+
 func (h *Handler) GetSchema(c *gin.Context) {
-	schema, err := h.service.getDatabaseSchema()
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+
+	schema, err := h.service.SchemaForUser(c.Request.Context(), userID.(string), middleware.HasPermission(c, costPermission))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -49,3 +105,150 @@ func (h *Handler) GetSchema(c *gin.Context) {
 
 	c.JSON(http.StatusOK, schema)
 }
+
+func (h *Handler) CreateConversation(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	var request ConversationRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.service.CreateConversation(c.Request.Context(), userID.(string), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, conversation)
+}
+
+func (h *Handler) GetConversations(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+
+	conversations, err := h.service.FindConversationsByUserID(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversations)
+}
+
+func (h *Handler) GetConversation(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+
+	conversation, err := h.service.FindConversation(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+func (h *Handler) DeleteConversation(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+
+	if err := h.service.DeleteConversation(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *Handler) SendMessage(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	var request MessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.SendMessage(c.Request.Context(), userID.(string), c.Param("id"), request, middleware.HasPermission(c, costPermission))
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetHistory(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+
+	history, err := h.service.FindHistory(c.Request.Context(), userID.(string), c.Query("limit"), c.Query("offset"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// errorStatus tradueix els errors del servei al codi HTTP corresponent.
+func errorStatus(err error) int {
+	var unsafeErr *UnsafeQueryError
+	switch {
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrStrategicDisabled):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrConversationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrStrategicNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.As(err, &unsafeErr):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorBody afegeix a l'error els intents de SQL fallits, si n'hi ha, per poder-los depurar.
+func errorBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+	var attemptsErr *QueryAttemptsError
+	if errors.As(err, &attemptsErr) {
+		body["attempts"] = attemptsErr.Attempts
+	}
+	return body
+}
+
+// callerID retorna l'usuari autenticat que el middleware JWT deixa al context.
+func callerID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("id")
+	if !exists {
+		return uuid.Nil, false
+	}
+	uid, err := uuid.Parse(userID.(string))
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return uid, true
+}
@@ -1,20 +1,40 @@
 package llm
 
 import (
+	"orkestra-api/internal/projects"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Modes de resposta de l'assistent.
+const (
+	ModeAuto      = "auto"
+	ModeSQL       = "sql"
+	ModeStrategic = "strategic"
+)
+
 type QueryRequest struct {
 	Question string `json:"question" binding:"required"`
-	UserID   uuid.UUID `json:"user_id"`
+	Mode     string `json:"mode" binding:"omitempty,oneof=auto sql strategic"`
+	UserID   uuid.UUID `json:"-"`
+	History  []ConversationTurn `json:"-"`
+	ConversationID uuid.UUID `json:"-"`
+	// ReadCost indica que el perfil té operators:read_cost i pot veure el cost dels operaris
+	ReadCost bool `json:"-"`
 }
 
 type QueryResponse struct {
+	QueryID   uuid.UUID `json:"query_id"`
 	Answer    string `json:"answer"`
+	Mode      string `json:"mode"`
 	Query     string `json:"query,omitempty"`
 	Data      interface{} `json:"data,omitempty"`
+	Truncated bool `json:"truncated,omitempty"`
+	Proposal  []projects.OperatorToProjectRequest `json:"proposal,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Attempts  []QueryAttempt `json:"attempts,omitempty"`
+	Cache     string `json:"cache"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
@@ -42,3 +62,59 @@ type Relationship struct {
 	ToColumn   string `json:"to_column"`
 	Type       string `json:"type"` // "one_to_many", "many_to_one", "many_to_many"
 }
+
+type Conversation struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	Title     string             `json:"title"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Turns     []ConversationTurn `json:"turns,omitempty"`
+}
+
+type ConversationTurn struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	Question       string    `json:"question"`
+	SQLQuery       string    `json:"sql_query,omitempty"`
+	ResultSummary  string    `json:"result_summary,omitempty"`
+	Answer         string    `json:"answer"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// QueryLog és una entrada de llm_queries: qui ha preguntat què, amb quin model i què ha costat.
+type QueryLog struct {
+	ID               uuid.UUID  `json:"id"`
+	UserID           uuid.UUID  `json:"user_id"`
+	ConversationID   *uuid.UUID `json:"conversation_id,omitempty"`
+	Question         string     `json:"question"`
+	Mode             string     `json:"mode"`
+	CacheStatus      string     `json:"cache_status,omitempty"`
+	Provider         string     `json:"provider"`
+	Model            string     `json:"model"`
+	SQLQuery         string     `json:"sql_query,omitempty"`
+	RowCount         int        `json:"row_count"`
+	ClassifyMs       int        `json:"classify_ms"`
+	SQLGenerationMs  int        `json:"sql_generation_ms"`
+	ExecutionMs      int        `json:"execution_ms"`
+	AnswerMs         int        `json:"answer_ms"`
+	TotalMs          int        `json:"total_ms"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	LLMCalls         int        `json:"llm_calls"`
+	Error            string     `json:"error,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type QueryUsage struct {
+	Queries int
+	Tokens  int
+}
+
+// QueryAttempt és cada una de les consultes que ha generat el model per respondre una pregunta.
+type QueryAttempt struct {
+	Attempt int    `json:"attempt"`
+	Query   string `json:"query"`
+	Error   string `json:"error,omitempty"`
+	Rows    int    `json:"rows"`
+}
\ No newline at end of file
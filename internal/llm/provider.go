@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"orkestra-api/config"
+	"sort"
+	"time"
+)
+
+// Provider és qualsevol model de llenguatge capaç de respondre un prompt de text.
+type Provider interface {
+	Name() string
+	Model() string
+	Complete(ctx context.Context, prompt string) (Completion, error)
+}
+
+// Completion és la resposta d'un proveïdor. Els comptadors de tokens queden a zero
+// quan el proveïdor no els informa.
+type Completion struct {
+	Text             string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// StreamingProvider és un proveïdor que pot retornar la resposta a trossos mentre es genera.
+// onChunk es crida per cada fragment i Stream retorna el text complet.
+type StreamingProvider interface {
+	Provider
+	Stream(ctx context.Context, prompt string, onChunk func(string) error) (Completion, error)
+}
+
+// ProviderConfig agrupa els paràmetres que cada proveïdor fa servir per cridar el model.
+type ProviderConfig struct {
+	Name        string
+	APIKey      string
+	URL         string
+	Model       string
+	Temperature float64
+	MaxTokens   int
+	Timeout     time.Duration
+}
+
+// ProviderFactory construeix un proveïdor a partir de la seva configuració.
+type ProviderFactory func(cfg ProviderConfig) (Provider, error)
+
+type registeredProvider struct {
+	factory      ProviderFactory
+	defaultModel string
+}
+
+var providerRegistry = map[string]registeredProvider{
+	"openai":     {factory: newOpenAIProvider, defaultModel: "gpt-3.5-turbo"},
+	"apifreellm": {factory: newFreeAPIProvider},
+	"gemini":     {factory: newGeminiProvider, defaultModel: "gemini-2.5-flash-lite"},
+}
+
+// RegisterProvider afegeix (o substitueix) un proveïdor al registre.
+func RegisterProvider(name, defaultModel string, factory ProviderFactory) {
+	providerRegistry[name] = registeredProvider{factory: factory, defaultModel: defaultModel}
+}
+
+// ProviderNames retorna els noms dels proveïdors registrats.
+func ProviderNames() []string {
+	names := make([]string, 0, len(providerRegistry))
+	for name := range providerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider crea el proveïdor indicat a cfg.Name, completant els valors que falten
+// amb els valors per defecte d'aquell proveïdor.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	entry, ok := providerRegistry[cfg.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q (available: %v)", cfg.Name, ProviderNames())
+	}
+	if cfg.Model == "" {
+		cfg.Model = entry.defaultModel
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return entry.factory(cfg)
+}
+
+// ProviderConfigFromEnv tradueix la configuració de l'aplicació a la del proveïdor actiu.
+func ProviderConfigFromEnv(cfg config.Config) ProviderConfig {
+	return ProviderConfig{
+		Name:        cfg.LLMProvider,
+		APIKey:      cfg.LLMApiKey,
+		URL:         cfg.LLMUrl,
+		Model:       cfg.LLMModel,
+		Temperature: cfg.LLMTemperature,
+		MaxTokens:   cfg.LLMMaxTokens,
+		Timeout:     time.Duration(cfg.LLMTimeoutSeconds) * time.Second,
+	}
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// freeAPIProvider crida apifreellm, que no accepta model, temperatura ni límit de tokens.
+type freeAPIProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+}
+
+func newFreeAPIProvider(cfg ProviderConfig) (Provider, error) {
+	return &freeAPIProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (p *freeAPIProvider) Name() string  { return "apifreellm" }
+func (p *freeAPIProvider) Model() string { return p.cfg.Model }
+
+func (p *freeAPIProvider) Complete(ctx context.Context, prompt string) (Completion, error) {
+	body, _ := json.Marshal(map[string]string{
+		"message": prompt,
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", p.cfg.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return Completion{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Completion{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return Completion{}, fmt.Errorf("apifreellm error: %s", string(raw))
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return Completion{}, err
+	}
+	if status, ok := result["status"].(string); ok && status == "success" {
+		return Completion{Text: fmt.Sprintf("%v", result["response"])}, nil
+	}
+	return Completion{}, fmt.Errorf("error: %v", result["error"])
+}
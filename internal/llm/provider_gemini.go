@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// geminiProvider reutilitza el mateix client de Gemini per a totes les crides.
+type geminiProvider struct {
+	cfg    ProviderConfig
+	client *genai.Client
+}
+
+func newGeminiProvider(cfg ProviderConfig) (Provider, error) {
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:  cfg.APIKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+	return &geminiProvider{cfg: cfg, client: client}, nil
+}
+
+func (p *geminiProvider) Name() string  { return "gemini" }
+func (p *geminiProvider) Model() string { return p.cfg.Model }
+
+func (p *geminiProvider) generateConfig() *genai.GenerateContentConfig {
+	return &genai.GenerateContentConfig{
+		Temperature:     genai.Ptr(float32(p.cfg.Temperature)),
+		MaxOutputTokens: int32(p.cfg.MaxTokens),
+	}
+}
+
+func (p *geminiProvider) Complete(ctx context.Context, prompt string) (Completion, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	response, err := p.client.Models.GenerateContent(ctx, p.cfg.Model, genai.Text(prompt), p.generateConfig())
+	if err != nil {
+		return Completion{}, fmt.Errorf("Gemini API error: %w", err)
+	}
+	completion := Completion{Text: response.Text()}
+	addGeminiUsage(&completion, response.UsageMetadata)
+	return completion, nil
+}
+
+func (p *geminiProvider) Stream(ctx context.Context, prompt string, onChunk func(string) error) (Completion, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	var sb strings.Builder
+	var completion Completion
+	for response, err := range p.client.Models.GenerateContentStream(ctx, p.cfg.Model, genai.Text(prompt), p.generateConfig()) {
+		if err != nil {
+			return Completion{}, fmt.Errorf("Gemini API error: %w", err)
+		}
+		// Cada fragment porta el recompte acumulat; ens quedem amb l'últim
+		addGeminiUsage(&completion, response.UsageMetadata)
+		chunk := response.Text()
+		if chunk == "" {
+			continue
+		}
+		sb.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			return Completion{}, err
+		}
+	}
+	completion.Text = sb.String()
+	return completion, nil
+}
+
+func addGeminiUsage(completion *Completion, usage *genai.GenerateContentResponseUsageMetadata) {
+	if usage == nil {
+		return
+	}
+	completion.PromptTokens = int(usage.PromptTokenCount)
+	completion.CompletionTokens = int(usage.CandidatesTokenCount)
+}
@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type openAIProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+}
+
+func newOpenAIProvider(cfg ProviderConfig) (Provider, error) {
+	return &openAIProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (p *openAIProvider) Name() string  { return "openai" }
+func (p *openAIProvider) Model() string { return p.cfg.Model }
+
+// openAIUsage és el recompte de tokens que l'API retorna amb cada resposta.
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (p *openAIProvider) newRequest(ctx context.Context, prompt string, stream bool) (*http.Request, error) {
+	payload := map[string]interface{}{
+		"model": p.cfg.Model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+		"max_tokens":  p.cfg.MaxTokens,
+		"temperature": p.cfg.Temperature,
+		"stream":      stream,
+	}
+	if stream {
+		// Sense això l'API no informa dels tokens consumits en mode streaming
+		payload["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", p.cfg.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	return req, nil
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, prompt string) (Completion, error) {
+	req, err := p.newRequest(ctx, prompt, false)
+	if err != nil {
+		return Completion{}, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Completion{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return Completion{}, fmt.Errorf("OpenAI API error: %s", string(raw))
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return Completion{}, err
+	}
+	if len(response.Choices) == 0 {
+		return Completion{}, fmt.Errorf("no response from LLM")
+	}
+
+	return Completion{
+		Text:             response.Choices[0].Message.Content,
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+	}, nil
+}
+
+// Stream llegeix la resposta en format SSE de l'API (línies "data: {...}" fins a "data: [DONE]").
+func (p *openAIProvider) Stream(ctx context.Context, prompt string, onChunk func(string) error) (Completion, error) {
+	req, err := p.newRequest(ctx, prompt, true)
+	if err != nil {
+		return Completion{}, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Completion{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		return Completion{}, fmt.Errorf("OpenAI API error: %s", string(raw))
+	}
+
+	var sb strings.Builder
+	var completion Completion
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if payload == "[DONE]" {
+			break
+		}
+
+		var event struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			return Completion{}, err
+		}
+		// L'últim esdeveniment no porta text, només el recompte de tokens
+		if event.Usage != nil {
+			completion.PromptTokens = event.Usage.PromptTokens
+			completion.CompletionTokens = event.Usage.CompletionTokens
+		}
+		if len(event.Choices) == 0 || event.Choices[0].Delta.Content == "" {
+			continue
+		}
+		chunk := event.Choices[0].Delta.Content
+		sb.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			return Completion{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Completion{}, err
+	}
+	completion.Text = sb.String()
+	return completion, nil
+}
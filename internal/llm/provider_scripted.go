@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ScriptStep és una resposta preparada del ScriptedProvider. Si Contains no és buit,
+// el pas només s'aplica quan el prompt conté aquest text.
+type ScriptStep struct {
+	Contains string
+	Reply    string
+	Err      error
+}
+
+// ScriptedProvider respon amb una seqüència fixa de respostes, sense accés a la xarxa.
+// Desa tots els prompts rebuts perquè es puguin inspeccionar després.
+type ScriptedProvider struct {
+	mu      sync.Mutex
+	steps   []ScriptStep
+	prompts []string
+}
+
+func NewScriptedProvider(steps ...ScriptStep) *ScriptedProvider {
+	return &ScriptedProvider{steps: steps}
+}
+
+func (p *ScriptedProvider) Name() string  { return "scripted" }
+func (p *ScriptedProvider) Model() string { return "scripted" }
+
+func (p *ScriptedProvider) Complete(ctx context.Context, prompt string) (Completion, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prompts = append(p.prompts, prompt)
+	for i, step := range p.steps {
+		if step.Contains != "" && !strings.Contains(prompt, step.Contains) {
+			continue
+		}
+		p.steps = append(p.steps[:i], p.steps[i+1:]...)
+		return Completion{Text: step.Reply}, step.Err
+	}
+	return Completion{}, fmt.Errorf("scripted provider: no reply left for prompt %d", len(p.prompts))
+}
+
+// Prompts retorna una còpia dels prompts rebuts fins ara, en ordre.
+func (p *ScriptedProvider) Prompts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.prompts...)
+}
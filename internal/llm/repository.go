@@ -0,0 +1,288 @@
+package llm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	CreateConversation(ctx context.Context, conversation Conversation) (Conversation, error)
+	FindConversationByID(ctx context.Context, id uuid.UUID) (Conversation, error)
+	FindConversationsByUserID(ctx context.Context, userID uuid.UUID) ([]Conversation, error)
+	DeleteConversation(ctx context.Context, id uuid.UUID) error
+	AddTurn(ctx context.Context, turn ConversationTurn) (ConversationTurn, error)
+	FindTurns(ctx context.Context, conversationID uuid.UUID, limit int) ([]ConversationTurn, error)
+	CreateQueryLog(ctx context.Context, entry QueryLog) error
+	FindQueryLogsByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]QueryLog, error)
+	UsageToday(ctx context.Context, userID uuid.UUID) (QueryUsage, error)
+	FindCachedQuery(ctx context.Context, key string) (CachedQuery, error)
+	SaveCachedQuery(ctx context.Context, entry CachedQuery) error
+	UpdateCachedAnswer(ctx context.Context, key, answer string, expiresAt *time.Time) error
+	TouchCachedQuery(ctx context.Context, key string) error
+	DeleteCachedQuery(ctx context.Context, key string) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateConversation(ctx context.Context, conversation Conversation) (Conversation, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO llm_conversations (id, user_id, title, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING created_at, updated_at`,
+		conversation.ID, conversation.UserID, conversation.Title,
+	).Scan(&conversation.CreatedAt, &conversation.UpdatedAt)
+	if err != nil {
+		return Conversation{}, err
+	}
+	return conversation, nil
+}
+
+func (r *repository) FindConversationByID(ctx context.Context, id uuid.UUID) (Conversation, error) {
+	var conversation Conversation
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, title, created_at, updated_at
+		FROM llm_conversations
+		WHERE id = $1`,
+		id,
+	).Scan(&conversation.ID, &conversation.UserID, &conversation.Title, &conversation.CreatedAt, &conversation.UpdatedAt)
+	if err != nil {
+		return Conversation{}, err
+	}
+	return conversation, nil
+}
+
+func (r *repository) FindConversationsByUserID(ctx context.Context, userID uuid.UUID) ([]Conversation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, title, created_at, updated_at
+		FROM llm_conversations
+		WHERE user_id = $1
+		ORDER BY updated_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []Conversation{}
+	for rows.Next() {
+		var conversation Conversation
+		if err := rows.Scan(&conversation.ID, &conversation.UserID, &conversation.Title, &conversation.CreatedAt, &conversation.UpdatedAt); err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conversation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+func (r *repository) DeleteConversation(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM llm_conversations
+		WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+// AddTurn desa un torn i actualitza la data de la conversa dins la mateixa transacció.
+func (r *repository) AddTurn(ctx context.Context, turn ConversationTurn) (ConversationTurn, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ConversationTurn{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO llm_conversation_turns (id, conversation_id, question, sql_query, result_summary, answer, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING created_at`,
+		turn.ID, turn.ConversationID, turn.Question, turn.SQLQuery, turn.ResultSummary, turn.Answer,
+	).Scan(&turn.CreatedAt)
+	if err != nil {
+		return ConversationTurn{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE llm_conversations
+		SET updated_at = NOW()
+		WHERE id = $1`,
+		turn.ConversationID,
+	)
+	if err != nil {
+		return ConversationTurn{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ConversationTurn{}, err
+	}
+	return turn, nil
+}
+
+// FindTurns retorna els torns d'una conversa en ordre cronològic. Amb limit > 0 només
+// es retornen els últims limit torns.
+func (r *repository) FindTurns(ctx context.Context, conversationID uuid.UUID, limit int) ([]ConversationTurn, error) {
+	query := `
+		SELECT id, conversation_id, question, COALESCE(sql_query, ''), COALESCE(result_summary, ''), COALESCE(answer, ''), created_at
+		FROM llm_conversation_turns
+		WHERE conversation_id = $1
+		ORDER BY created_at DESC`
+	args := []interface{}{conversationID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	turns := []ConversationTurn{}
+	for rows.Next() {
+		var turn ConversationTurn
+		if err := rows.Scan(&turn.ID, &turn.ConversationID, &turn.Question, &turn.SQLQuery, &turn.ResultSummary, &turn.Answer, &turn.CreatedAt); err != nil {
+			return nil, err
+		}
+		turns = append(turns, turn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Les hem llegit de la més nova a la més antiga
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return turns, nil
+}
+
+func (r *repository) CreateQueryLog(ctx context.Context, entry QueryLog) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO llm_queries (id, user_id, conversation_id, question, mode, provider, model, sql_query,
+			row_count, classify_ms, sql_generation_ms, execution_ms, answer_ms, total_ms,
+			prompt_tokens, completion_tokens, llm_calls, error, cache_status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13, $14, $15, $16, $17, NULLIF($18, ''), NULLIF($19, ''), NOW())`,
+		entry.ID, entry.UserID, entry.ConversationID, entry.Question, entry.Mode, entry.Provider, entry.Model, entry.SQLQuery,
+		entry.RowCount, entry.ClassifyMs, entry.SQLGenerationMs, entry.ExecutionMs, entry.AnswerMs, entry.TotalMs,
+		entry.PromptTokens, entry.CompletionTokens, entry.LLMCalls, entry.Error, entry.CacheStatus,
+	)
+	return err
+}
+
+func (r *repository) FindQueryLogsByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]QueryLog, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, conversation_id, question, mode, COALESCE(cache_status, ''), provider, model, COALESCE(sql_query, ''),
+			row_count, classify_ms, sql_generation_ms, execution_ms, answer_ms, total_ms,
+			prompt_tokens, completion_tokens, llm_calls, COALESCE(error, ''), created_at
+		FROM llm_queries
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []QueryLog{}
+	for rows.Next() {
+		var entry QueryLog
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.ConversationID, &entry.Question, &entry.Mode, &entry.CacheStatus, &entry.Provider, &entry.Model, &entry.SQLQuery,
+			&entry.RowCount, &entry.ClassifyMs, &entry.SQLGenerationMs, &entry.ExecutionMs, &entry.AnswerMs, &entry.TotalMs,
+			&entry.PromptTokens, &entry.CompletionTokens, &entry.LLMCalls, &entry.Error, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// UsageToday compta les preguntes i els tokens que l'usuari ha consumit des de l'inici del dia.
+func (r *repository) UsageToday(ctx context.Context, userID uuid.UUID) (QueryUsage, error) {
+	var usage QueryUsage
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens + completion_tokens), 0)
+		FROM llm_queries
+		WHERE user_id = $1 AND created_at >= date_trunc('day', NOW())`,
+		userID,
+	).Scan(&usage.Queries, &usage.Tokens)
+	if err != nil {
+		return QueryUsage{}, err
+	}
+	return usage, nil
+}
+
+func (r *repository) FindCachedQuery(ctx context.Context, key string) (CachedQuery, error) {
+	var entry CachedQuery
+	err := r.db.QueryRowContext(ctx, `
+		SELECT cache_key, question, scope, schema_version, sql_query, COALESCE(answer, ''), answer_expires_at, hits
+		FROM llm_query_cache
+		WHERE cache_key = $1`,
+		key,
+	).Scan(&entry.Key, &entry.Question, &entry.Scope, &entry.SchemaVersion, &entry.SQLQuery, &entry.Answer, &entry.AnswerExpiresAt, &entry.Hits)
+	if err != nil {
+		return CachedQuery{}, err
+	}
+	return entry, nil
+}
+
+func (r *repository) SaveCachedQuery(ctx context.Context, entry CachedQuery) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO llm_query_cache (cache_key, question, scope, schema_version, sql_query, answer, answer_expires_at, hits, created_at, last_hit_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, 0, NOW(), NULL)
+		ON CONFLICT (cache_key) DO UPDATE
+		SET sql_query = EXCLUDED.sql_query, answer = EXCLUDED.answer, answer_expires_at = EXCLUDED.answer_expires_at`,
+		entry.Key, entry.Question, entry.Scope, entry.SchemaVersion, entry.SQLQuery, entry.Answer, entry.AnswerExpiresAt,
+	)
+	return err
+}
+
+// UpdateCachedAnswer compta un encert i substitueix la resposta desada (o l'esborra si expiresAt és nil).
+func (r *repository) UpdateCachedAnswer(ctx context.Context, key, answer string, expiresAt *time.Time) error {
+	if expiresAt == nil {
+		answer = ""
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE llm_query_cache
+		SET answer = NULLIF($2, ''), answer_expires_at = $3, hits = hits + 1, last_hit_at = NOW()
+		WHERE cache_key = $1`,
+		key, answer, expiresAt,
+	)
+	return err
+}
+
+func (r *repository) TouchCachedQuery(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE llm_query_cache
+		SET hits = hits + 1, last_hit_at = NOW()
+		WHERE cache_key = $1`,
+		key,
+	)
+	return err
+}
+
+func (r *repository) DeleteCachedQuery(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM llm_query_cache
+		WHERE cache_key = $1`,
+		key,
+	)
+	return err
+}
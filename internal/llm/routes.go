@@ -1,14 +1,23 @@
 package llm
 
 import (
+	"orkestra-api/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
 //func RegisterRoutes(router *gin.RouterGroup, handler *OperatorHandler) {
-func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
-	llmGroup := router.Group("/llm")
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, authz *middleware.Authorizer) {
+	llmGroup := router.Group("/llm", authz.Require("llm:query"))
 	{
 		llmGroup.POST("/query", handler.Query)
+		llmGroup.POST("/query/stream", handler.QueryStream)
 		llmGroup.GET("/schema", handler.GetSchema)
+		llmGroup.GET("/history", handler.GetHistory)
+		llmGroup.POST("/conversations", handler.CreateConversation)
+		llmGroup.GET("/conversations", handler.GetConversations)
+		llmGroup.GET("/conversations/:id", handler.GetConversation)
+		llmGroup.DELETE("/conversations/:id", handler.DeleteConversation)
+		llmGroup.POST("/conversations/:id/messages", handler.SendMessage)
 	}
 }
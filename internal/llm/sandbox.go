@@ -0,0 +1,289 @@
+package llm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Paraules clau que mai poden aparèixer en una consulta generada pel model.
+var forbiddenKeywords = map[string]struct{}{
+	"INSERT": {}, "UPDATE": {}, "DELETE": {}, "MERGE": {}, "UPSERT": {},
+	"DROP": {}, "ALTER": {}, "CREATE": {}, "TRUNCATE": {}, "RENAME": {},
+	"GRANT": {}, "REVOKE": {}, "COPY": {}, "VACUUM": {}, "ANALYZE": {},
+	"REINDEX": {}, "CLUSTER": {}, "COMMENT": {}, "SECURITY": {}, "OWNER": {},
+	"LOCK": {}, "CALL": {}, "DO": {}, "EXECUTE": {}, "PREPARE": {},
+	"DEALLOCATE": {}, "LISTEN": {}, "NOTIFY": {}, "UNLISTEN": {},
+	"SET": {}, "RESET": {}, "BEGIN": {}, "COMMIT": {}, "ROLLBACK": {},
+	"SAVEPOINT": {}, "REFRESH": {}, "IMPORT": {}, "LOAD": {}, "INTO": {},
+}
+
+// Funcions que pot cridar una consulta generada. Qualsevol altra paraula seguida d'un parèntesi
+// es rebutja: algunes funcions (ts_stat, ts_rewrite, query_to_xml...) executen SQL passat
+// com a text, que no passa pel validador ni per les CTE de scopeQuery.
+var allowedFunctions = map[string]struct{}{
+	// Agregats i finestres
+	"COUNT": {}, "SUM": {}, "AVG": {}, "MIN": {}, "MAX": {}, "STRING_AGG": {}, "ARRAY_AGG": {},
+	"BOOL_AND": {}, "BOOL_OR": {}, "EVERY": {}, "STDDEV": {}, "STDDEV_POP": {}, "STDDEV_SAMP": {},
+	"VARIANCE": {}, "VAR_POP": {}, "VAR_SAMP": {}, "PERCENTILE_CONT": {}, "PERCENTILE_DISC": {}, "MODE": {},
+	"ROW_NUMBER": {}, "RANK": {}, "DENSE_RANK": {}, "PERCENT_RANK": {}, "CUME_DIST": {}, "NTILE": {},
+	"LAG": {}, "LEAD": {}, "FIRST_VALUE": {}, "LAST_VALUE": {}, "NTH_VALUE": {},
+	// Condicionals
+	"COALESCE": {}, "NULLIF": {}, "GREATEST": {}, "LEAST": {},
+	// Text
+	"LOWER": {}, "UPPER": {}, "INITCAP": {}, "LENGTH": {}, "CHAR_LENGTH": {}, "CHARACTER_LENGTH": {},
+	"TRIM": {}, "LTRIM": {}, "RTRIM": {}, "BTRIM": {}, "SUBSTRING": {}, "SUBSTR": {}, "POSITION": {},
+	"STRPOS": {}, "OVERLAY": {}, "REPLACE": {}, "CONCAT": {}, "CONCAT_WS": {}, "LEFT": {}, "RIGHT": {},
+	"LPAD": {}, "RPAD": {}, "SPLIT_PART": {}, "REVERSE": {}, "REPEAT": {}, "UNACCENT": {},
+	"TO_CHAR": {}, "TO_NUMBER": {}, "TO_DATE": {}, "TO_TIMESTAMP": {},
+	// Números
+	"ROUND": {}, "CEIL": {}, "CEILING": {}, "FLOOR": {}, "ABS": {}, "TRUNC": {}, "MOD": {},
+	"POWER": {}, "SQRT": {}, "SIGN": {}, "DIV": {},
+	// Dates
+	"NOW": {}, "DATE_TRUNC": {}, "DATE_PART": {}, "EXTRACT": {}, "AGE": {}, "MAKE_DATE": {},
+	"MAKE_TIME": {}, "MAKE_TIMESTAMP": {}, "MAKE_TIMESTAMPTZ": {}, "MAKE_INTERVAL": {},
+	"JUSTIFY_DAYS": {}, "JUSTIFY_HOURS": {}, "JUSTIFY_INTERVAL": {}, "ISFINITE": {}, "GENERATE_SERIES": {},
+	// Arrays i JSON
+	"ARRAY_LENGTH": {}, "CARDINALITY": {}, "UNNEST": {}, "ARRAY_TO_STRING": {}, "ARRAY_POSITION": {},
+	"JSON_BUILD_OBJECT": {}, "JSONB_BUILD_OBJECT": {}, "JSON_AGG": {}, "JSONB_AGG": {},
+	"JSON_OBJECT_AGG": {}, "JSONB_OBJECT_AGG": {},
+}
+
+// Paraules clau i tipus que poden anar seguits d'un parèntesi sense ser una crida a funció.
+var keywordsBeforeParen = map[string]struct{}{
+	"SELECT": {}, "FROM": {}, "WHERE": {}, "AND": {}, "OR": {}, "NOT": {}, "IN": {}, "EXISTS": {},
+	"ANY": {}, "ALL": {}, "SOME": {}, "AS": {}, "ON": {}, "USING": {}, "JOIN": {}, "LATERAL": {},
+	"VALUES": {}, "OVER": {}, "FILTER": {}, "GROUP": {}, "BY": {}, "HAVING": {}, "CASE": {}, "WHEN": {},
+	"THEN": {}, "ELSE": {}, "BETWEEN": {}, "LIKE": {}, "ILIKE": {}, "IS": {}, "DISTINCT": {},
+	"UNION": {}, "INTERSECT": {}, "EXCEPT": {}, "LIMIT": {}, "OFFSET": {}, "CAST": {}, "ROW": {},
+	"ARRAY": {}, "INTERVAL": {}, "NUMERIC": {}, "DECIMAL": {}, "VARCHAR": {}, "CHAR": {},
+	"CHARACTER": {}, "VARYING": {}, "TIMESTAMP": {}, "TIME": {}, "FLOAT": {},
+}
+
+type sqlTokenKind int
+
+const (
+	tokenWord sqlTokenKind = iota
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type sqlToken struct {
+	kind  sqlTokenKind
+	value string
+}
+
+// tokenizeSQL separa la consulta en tokens ignorant comentaris i el contingut dels literals,
+// de manera que una paraula prohibida dins d'un text no faci fallar la validació.
+func tokenizeSQL(query string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(query)
+	n := len(runes)
+
+	for i := 0; i < n; {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < n && runes[i+1] == '-':
+			for i < n && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < n && runes[i+1] == '*':
+			j := i + 2
+			for j+1 < n && !(runes[j] == '*' && runes[j+1] == '/') {
+				j++
+			}
+			if j+1 >= n {
+				return nil, unsafeQuery("unterminated comment")
+			}
+			i = j + 2
+		case r == '\'':
+			j := i + 1
+			for {
+				if j >= n {
+					return nil, unsafeQuery("unterminated string literal")
+				}
+				if runes[j] == '\\' {
+					// Amb E'...' la barra escapa cometes i podríem perdre el final del literal
+					return nil, unsafeQuery("backslashes in string literals are not allowed")
+				}
+				if runes[j] == '\'' {
+					if j+1 < n && runes[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenString, value: string(runes[i+1 : j])})
+			i = j + 1
+		case r == '"':
+			j := i + 1
+			for j < n && runes[j] != '"' {
+				j++
+			}
+			if j >= n {
+				return nil, unsafeQuery("unterminated quoted identifier")
+			}
+			tokens = append(tokens, sqlToken{kind: tokenQuotedIdent, value: strings.ToUpper(string(runes[i+1 : j]))})
+			i = j + 1
+		case r == '$':
+			// Els literals amb dòlars ($$...$$) només serveixen per amagar codi
+			return nil, unsafeQuery("dollar-quoted strings are not allowed")
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < n && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenWord, value: strings.ToUpper(string(runes[i:j]))})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < n && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenNumber, value: string(runes[i:j])})
+			i = j
+		default:
+			tokens = append(tokens, sqlToken{kind: tokenSymbol, value: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// validateSQL comprova que la consulta sigui una única sentència SELECT/WITH de només lectura
+// i que no faci referència als catàlegs del sistema ni a cap identificador de denied.
+func validateSQL(query string, denied map[string]struct{}) (string, error) {
+	query = strings.TrimSpace(query)
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return "", err
+	}
+
+	// Acceptem un únic punt i coma final, que traiem abans d'executar
+	for len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenSymbol && tokens[len(tokens)-1].value == ";" {
+		tokens = tokens[:len(tokens)-1]
+		query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+	}
+	if len(tokens) == 0 {
+		return "", unsafeQuery("empty query")
+	}
+	if tokens[0].kind != tokenWord || (tokens[0].value != "SELECT" && tokens[0].value != "WITH") {
+		return "", unsafeQuery("only SELECT or WITH statements are allowed")
+	}
+
+	for i, tok := range tokens {
+		switch tok.kind {
+		case tokenSymbol:
+			if tok.value == ";" {
+				return "", unsafeQuery("multiple statements are not allowed")
+			}
+		case tokenWord, tokenQuotedIdent:
+			if tok.kind == tokenWord {
+				if _, ok := forbiddenKeywords[tok.value]; ok {
+					return "", unsafeQuery(fmt.Sprintf("keyword %s is not allowed", tok.value))
+				}
+				// FOR UPDATE / FOR SHARE bloquegen files encara que sigui un SELECT
+				if tok.value == "FOR" && i+1 < len(tokens) {
+					next := tokens[i+1].value
+					if next == "UPDATE" || next == "SHARE" || next == "NO" || next == "KEY" {
+						return "", unsafeQuery("row locking clauses are not allowed")
+					}
+				}
+			}
+			if i+1 < len(tokens) && tokens[i+1].kind == tokenSymbol && tokens[i+1].value == "(" && !callAllowed(tok) {
+				return "", unsafeQuery(fmt.Sprintf("function %s is not allowed", strings.ToLower(tok.value)))
+			}
+			if tok.value == "INFORMATION_SCHEMA" || tok.value == "PG_CATALOG" || strings.HasPrefix(tok.value, "PG_") {
+				return "", unsafeQuery("system catalogs are not allowed")
+			}
+			if _, ok := denied[tok.value]; ok {
+				return "", unsafeQuery(fmt.Sprintf("%s is not available", strings.ToLower(tok.value)))
+			}
+		}
+	}
+
+	return query, nil
+}
+
+// callAllowed indica si el token, seguit d'un parèntesi, és una funció permesa o una paraula
+// clau. Un identificador entre cometes sempre és una crida.
+func callAllowed(tok sqlToken) bool {
+	if _, ok := allowedFunctions[tok.value]; ok {
+		return true
+	}
+	if tok.kind != tokenWord {
+		return false
+	}
+	_, ok := keywordsBeforeParen[tok.value]
+	return ok
+}
+
+// runReadOnly executa la consulta dins d'una transacció READ ONLY amb un temps màxim
+// d'execució i retorna com a molt maxRows files.
+func (s *Service) runReadOnly(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, bool, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, false, err
+	}
+	// Mai fem commit: la transacció només existeix per aïllar la lectura
+	defer tx.Rollback()
+
+	if s.cfg.LLMStatementTimeoutMs > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", s.cfg.LLMStatementTimeoutMs)); err != nil {
+			return nil, false, err
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var results []map[string]interface{}
+	truncated := false
+	for rows.Next() {
+		if s.cfg.LLMMaxRows > 0 && len(results) >= s.cfg.LLMMaxRows {
+			truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, false, err
+		}
+
+		row := make(map[string]interface{})
+		for i, col := range columns {
+			val := values[i]
+			if b, ok := val.([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = val
+			}
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	return results, truncated, nil
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateSQL(t *testing.T) {
+	denied := map[string]struct{}{"PUBLIC": {}, "USERS": {}, "PASSWORD": {}}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		ok    bool
+	}{
+		{name: "select", query: "SELECT name FROM projects", want: "SELECT name FROM projects", ok: true},
+		{name: "with", query: "WITH p AS (SELECT id FROM projects) SELECT count(*) FROM p", want: "WITH p AS (SELECT id FROM projects) SELECT count(*) FROM p", ok: true},
+		{name: "trailing semicolon", query: "  SELECT 1;  ", want: "SELECT 1", ok: true},
+		{name: "keyword inside literal", query: "SELECT name FROM projects WHERE name = 'DROP TABLE'", want: "SELECT name FROM projects WHERE name = 'DROP TABLE'", ok: true},
+		{name: "escaped quote", query: "SELECT 'l''obra'", want: "SELECT 'l''obra'", ok: true},
+		{name: "keyword in comment", query: "SELECT 1 -- DELETE\n", want: "SELECT 1 -- DELETE", ok: true},
+		{name: "allowed functions", query: "SELECT date_trunc('month', start_date), count(*), coalesce(sum(amount), 0) FROM projects GROUP BY 1", want: "SELECT date_trunc('month', start_date), count(*), coalesce(sum(amount), 0) FROM projects GROUP BY 1", ok: true},
+		{name: "keywords before parenthesis", query: "SELECT CAST(amount AS numeric(10, 2)), rank() OVER (PARTITION BY customer_id ORDER BY amount) FROM projects WHERE id IN (SELECT project_id FROM tasks) AND EXISTS (SELECT 1)", want: "SELECT CAST(amount AS numeric(10, 2)), rank() OVER (PARTITION BY customer_id ORDER BY amount) FROM projects WHERE id IN (SELECT project_id FROM tasks) AND EXISTS (SELECT 1)", ok: true},
+		{name: "extract", query: "SELECT extract(year FROM start_date) FROM projects", want: "SELECT extract(year FROM start_date) FROM projects", ok: true},
+		{name: "empty", query: "  ;", ok: false},
+		{name: "ts_stat runs sql from a literal", query: "SELECT * FROM ts_stat('SELECT to_tsvector(''simple'', cost::text) FROM operators')", ok: false},
+		{name: "ts_rewrite runs sql from a literal", query: "SELECT ts_rewrite('a'::tsquery, 'SELECT t, s FROM aliases')", ok: false},
+		{name: "quoted function name", query: `SELECT * FROM "ts_stat"('SELECT 1')`, ok: false},
+		{name: "query_to_xml", query: "SELECT query_to_xml('SELECT * FROM users', true, true, '')", ok: false},
+		{name: "unknown function", query: "SELECT xmltable('/a' PASSING '<a/>' COLUMNS x text)", ok: false},
+		{name: "delete", query: "DELETE FROM projects", ok: false},
+		{name: "multiple statements", query: "SELECT 1; DROP TABLE projects", ok: false},
+		{name: "data-modifying cte", query: "WITH d AS (DELETE FROM projects RETURNING id) SELECT * FROM d", ok: false},
+		{name: "select into", query: "SELECT * INTO copy FROM projects", ok: false},
+		{name: "row locking", query: "SELECT id FROM projects FOR UPDATE", ok: false},
+		{name: "forbidden function", query: "SELECT pg_sleep(10)", ok: false},
+		{name: "system catalog", query: "SELECT * FROM information_schema.tables", ok: false},
+		{name: "pg prefix", query: "SELECT * FROM pg_user", ok: false},
+		{name: "denied table", query: "SELECT id FROM users", ok: false},
+		{name: "denied quoted column", query: `SELECT "password" FROM projects`, ok: false},
+		{name: "schema prefix", query: "SELECT * FROM public.projects", ok: false},
+		{name: "dollar quoting", query: "SELECT $$x$$", ok: false},
+		{name: "backslash in literal", query: `SELECT E'\'' FROM projects`, ok: false},
+		{name: "unterminated literal", query: "SELECT 'x", ok: false},
+		{name: "unterminated comment", query: "SELECT 1 /* x", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateSQL(tt.query, denied)
+			if !tt.ok {
+				var unsafe *UnsafeQueryError
+				if !errors.As(err, &unsafe) {
+					t.Fatalf("validateSQL(%q) = %q, %v; want UnsafeQueryError", tt.query, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateSQL(%q): %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("validateSQL(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package llm
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+//go:embed schema_catalog.json
+var defaultSchemaCatalog []byte
+
+// SchemaCatalog és la llista curada de taules que el model pot consultar,
+// amb les descripcions en català que s'afegeixen a l'esquema real.
+type SchemaCatalog struct {
+	Tables        []CatalogTable `json:"tables"`
+	Relationships []Relationship `json:"relationships"`
+}
+
+type CatalogTable struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	Columns       map[string]string `json:"columns"`
+	HiddenColumns []string          `json:"hidden_columns"`
+}
+
+// loadSchemaCatalog llegeix el catàleg del fitxer configurat o, si no n'hi ha cap,
+// el que va incrustat al binari.
+func loadSchemaCatalog(path string) (*SchemaCatalog, error) {
+	raw := defaultSchemaCatalog
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading schema catalog %s: %w", path, err)
+		}
+		raw = content
+	}
+
+	var catalog SchemaCatalog
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		return nil, fmt.Errorf("error parsing schema catalog: %w", err)
+	}
+	return &catalog, nil
+}
+
+type dbColumn struct {
+	name     string
+	dataType string
+}
+
+// introspectColumns retorna les columnes de totes les taules i vistes de l'esquema public.
+func introspectColumns(ctx context.Context, db *sql.DB) (map[string][]dbColumn, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT table_name, column_name, data_type
+		FROM information_schema.columns
+		WHERE table_schema = 'public'
+		ORDER BY table_name, ordinal_position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string][]dbColumn)
+	for rows.Next() {
+		var table string
+		var col dbColumn
+		if err := rows.Scan(&table, &col.name, &col.dataType); err != nil {
+			return nil, err
+		}
+		tables[table] = append(tables[table], col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// introspectForeignKeys llegeix les claus foranes declarades a l'esquema public.
+func introspectForeignKeys(ctx context.Context, db *sql.DB) ([]Relationship, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT cl.relname, att.attname, fcl.relname, fatt.attname
+		FROM pg_constraint con
+			INNER JOIN pg_class cl ON cl.oid = con.conrelid
+			INNER JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+			INNER JOIN pg_class fcl ON fcl.oid = con.confrelid
+			CROSS JOIN LATERAL unnest(con.conkey, con.confkey) AS k(attnum, fattnum)
+			INNER JOIN pg_attribute att ON att.attrelid = con.conrelid AND att.attnum = k.attnum
+			INNER JOIN pg_attribute fatt ON fatt.attrelid = con.confrelid AND fatt.attnum = k.fattnum
+		WHERE con.contype = 'f' AND ns.nspname = 'public'
+		ORDER BY cl.relname, att.attname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relationships []Relationship
+	for rows.Next() {
+		rel := Relationship{Type: "many_to_one"}
+		if err := rows.Scan(&rel.FromTable, &rel.FromColumn, &rel.ToTable, &rel.ToColumn); err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, rel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return relationships, nil
+}
+
+// buildSchema combina l'esquema real amb el catàleg. Només s'exposen les taules del catàleg
+// que existeixen a la base de dades i, de cada taula, només les columnes que el catàleg
+// descriu. Una columna nova de la base de dades no arriba al model fins que s'afegeix al
+// catàleg, i les de hidden_columns no hi arriben mai encara que s'hi afegeixin.
+func buildSchema(catalog *SchemaCatalog, columns map[string][]dbColumn, foreignKeys []Relationship) (*QueryContext, map[string]struct{}) {
+	schema := &QueryContext{}
+	allowed := make(map[string]struct{})
+	visibleColumns := make(map[string]struct{})
+	hiddenColumns := make(map[string]struct{})
+
+	for _, entry := range catalog.Tables {
+		dbCols, ok := columns[entry.Name]
+		if !ok {
+			log.Printf("llm schema: table %s is in the catalog but not in the database", entry.Name)
+			continue
+		}
+		hidden := make(map[string]struct{}, len(entry.HiddenColumns))
+		for _, col := range entry.HiddenColumns {
+			hidden[col] = struct{}{}
+		}
+
+		table := TableInfo{Name: entry.Name, Description: entry.Description}
+		for _, col := range dbCols {
+			_, listed := entry.Columns[col.name]
+			if _, ok := hidden[col.name]; ok || !listed {
+				hiddenColumns[strings.ToUpper(col.name)] = struct{}{}
+				continue
+			}
+			visibleColumns[strings.ToUpper(col.name)] = struct{}{}
+			table.Columns = append(table.Columns, ColumnInfo{
+				Name:        col.name,
+				Type:        col.dataType,
+				Description: entry.Columns[col.name],
+			})
+		}
+		schema.Tables = append(schema.Tables, table)
+		allowed[entry.Name] = struct{}{}
+	}
+
+	for _, rel := range append(foreignKeys, catalog.Relationships...) {
+		_, fromOK := allowed[rel.FromTable]
+		_, toOK := allowed[rel.ToTable]
+		if fromOK && toOK {
+			schema.Relationships = append(schema.Relationships, rel)
+		}
+	}
+
+	// Identificadors que el validador ha de rebutjar: taules fora del catàleg i columnes
+	// amagades. És només una capa més: el que protegeix les columnes són les CTE de scopeQuery.
+	denied := make(map[string]struct{})
+	// Sense prefix d'esquema no es poden saltar les CTE que substitueixen les taules
+	denied["PUBLIC"] = struct{}{}
+	for table := range columns {
+		if _, ok := allowed[table]; !ok {
+			denied[strings.ToUpper(table)] = struct{}{}
+		}
+	}
+	for col := range hiddenColumns {
+		if _, ok := visibleColumns[col]; !ok {
+			denied[col] = struct{}{}
+		}
+	}
+
+	return schema, denied
+}
+
+// LoadSchema construeix l'esquema que es passa al model a partir de la base de dades real.
+// S'ha de cridar a l'arrencada, abans de servir cap consulta.
+func (s *Service) LoadSchema(ctx context.Context) error {
+	catalog, err := loadSchemaCatalog(s.cfg.LLMSchemaFile)
+	if err != nil {
+		return err
+	}
+	columns, err := introspectColumns(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("error introspecting columns: %w", err)
+	}
+	foreignKeys, err := introspectForeignKeys(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("error introspecting foreign keys: %w", err)
+	}
+
+	s.setSchema(buildSchema(catalog, columns, foreignKeys))
+	return nil
+}
+
+// setSchema desa l'esquema complet i en deriva els dels abasts restringits.
+func (s *Service) setSchema(schema *QueryContext, denied map[string]struct{}) {
+	s.schema = schema
+	s.schemaVersion = schemaVersion(schema)
+	s.deniedIdentifiers = denied
+	s.customerSchema = buildCustomerSchema(schema, denied)
+	s.costlessSchema = buildCostlessSchema(schema, denied)
+}
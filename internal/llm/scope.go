@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// scopeRule descriu com es veu una taula per a un usuari de client: el filtre que
+// limita les files al seu client ($1) i les columnes que no ha de veure.
+type scopeRule struct {
+	filter        string
+	hiddenColumns []string
+}
+
+// Taules accessibles als usuaris de client. La resta (operaris, costos, usuaris,
+// reunions...) queden fora del seu abast.
+var customerScopeRules = map[string]scopeRule{
+	"customers":     {filter: "id = $1"},
+	"projects":      {filter: "customer_id = $1", hiddenColumns: []string{"estimated_cost"}},
+	"tasks":         {filter: "project_id IN (SELECT id FROM public.projects WHERE customer_id = $1)"},
+	"labels":        {},
+	"entity_labels": {filter: "entity_id IN (SELECT id FROM public.projects WHERE customer_id = $1)"},
+}
+
+// costPermission és el permís que dona accés al cost dels operaris.
+const costPermission = "operators:read_cost"
+
+// costColumns són les columnes amb el cost dels operaris, que només veuen els perfils
+// amb costPermission.
+var costColumns = map[string][]string{
+	"operators":             {"cost"},
+	"operators_to_projects": {"cost"},
+}
+
+// dataScope és el conjunt de dades que pot consultar qui fa la pregunta.
+// Amb customerID buit no hi ha cap restricció de files. Sense readCost no es veu el
+// cost dels operaris.
+type dataScope struct {
+	customerID uuid.UUID
+	readCost   bool
+}
+
+func (d dataScope) restricted() bool {
+	return d.customerID != uuid.Nil
+}
+
+// scopedSchema és l'esquema i els identificadors prohibits que corresponen a un abast restringit.
+type scopedSchema struct {
+	schema *QueryContext
+	denied map[string]struct{}
+}
+
+// resolveScope decideix l'abast de l'usuari igual que el llistat de projectes:
+// si està vinculat a un client, només veu les dades d'aquell client.
+func (s *Service) resolveScope(ctx context.Context, userID uuid.UUID, readCost bool) (dataScope, error) {
+	if userID == uuid.Nil {
+		return dataScope{}, ErrInvalidID
+	}
+	customer, err := s.customerService.FindCustomerByUserID(ctx, userID.String())
+	if err != nil {
+		return dataScope{}, fmt.Errorf("error finding customer by user ID: %w", err)
+	}
+	return dataScope{customerID: customer.ID, readCost: readCost}, nil
+}
+
+// schemaFor retorna l'esquema i els identificadors prohibits per a l'abast indicat.
+func (s *Service) schemaFor(scope dataScope) (*QueryContext, map[string]struct{}, error) {
+	if s.schema == nil {
+		return nil, nil, fmt.Errorf("database schema not loaded")
+	}
+	if scope.restricted() {
+		return s.customerSchema.schema, s.customerSchema.denied, nil
+	}
+	if !scope.readCost {
+		return s.costlessSchema.schema, s.costlessSchema.denied, nil
+	}
+	return s.schema, s.deniedIdentifiers, nil
+}
+
+// SchemaForUser retorna l'esquema que el model veuria per a les preguntes de l'usuari.
+func (s *Service) SchemaForUser(ctx context.Context, userID string, readCost bool) (*QueryContext, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	scope, err := s.resolveScope(ctx, userUUID, readCost)
+	if err != nil {
+		return nil, err
+	}
+	schema, _, err := s.schemaFor(scope)
+	return schema, err
+}
+
+// buildCustomerSchema redueix l'esquema complet a les taules i columnes de customerScopeRules.
+func buildCustomerSchema(schema *QueryContext, denied map[string]struct{}) scopedSchema {
+	return restrictSchema(schema, denied, func(table string) ([]string, bool) {
+		rule, ok := customerScopeRules[table]
+		return rule.hiddenColumns, ok
+	})
+}
+
+// buildCostlessSchema treu de l'esquema complet les columnes de costColumns.
+func buildCostlessSchema(schema *QueryContext, denied map[string]struct{}) scopedSchema {
+	return restrictSchema(schema, denied, func(table string) ([]string, bool) {
+		return costColumns[table], true
+	})
+}
+
+// restrictSchema deixa les taules per a les quals rule retorna ok, sense les columnes que
+// rule retorna, i afegeix a denied les taules i columnes que ja no hi són.
+func restrictSchema(schema *QueryContext, denied map[string]struct{}, rule func(table string) ([]string, bool)) scopedSchema {
+	scoped := scopedSchema{
+		schema: &QueryContext{},
+		denied: make(map[string]struct{}, len(denied)),
+	}
+	for name := range denied {
+		scoped.denied[name] = struct{}{}
+	}
+
+	kept := make(map[string]struct{})
+	visibleColumns := make(map[string]struct{})
+	hiddenColumns := make(map[string]struct{})
+	for _, table := range schema.Tables {
+		hiddenList, ok := rule(table.Name)
+		if !ok {
+			scoped.denied[strings.ToUpper(table.Name)] = struct{}{}
+			continue
+		}
+		kept[table.Name] = struct{}{}
+		hidden := make(map[string]struct{}, len(hiddenList))
+		for _, col := range hiddenList {
+			hidden[col] = struct{}{}
+		}
+
+		scopedTable := TableInfo{Name: table.Name, Description: table.Description}
+		for _, col := range table.Columns {
+			if _, ok := hidden[col.Name]; ok {
+				hiddenColumns[strings.ToUpper(col.Name)] = struct{}{}
+				continue
+			}
+			visibleColumns[strings.ToUpper(col.Name)] = struct{}{}
+			scopedTable.Columns = append(scopedTable.Columns, col)
+		}
+		scoped.schema.Tables = append(scoped.schema.Tables, scopedTable)
+	}
+	for col := range hiddenColumns {
+		if _, ok := visibleColumns[col]; !ok {
+			scoped.denied[col] = struct{}{}
+		}
+	}
+
+	for _, rel := range schema.Relationships {
+		_, fromOK := kept[rel.FromTable]
+		_, toOK := kept[rel.ToTable]
+		if fromOK && toOK {
+			scoped.schema.Relationships = append(scoped.schema.Relationships, rel)
+		}
+	}
+	return scoped
+}
+
+// scopeQuery embolcalla una consulta ja validada amb CTE que substitueixen cada taula de
+// l'esquema per una que només té les columnes visibles, i per a un abast restringit només
+// les files del client. La consulta original no pot arribar a cap altra columna, ni amb
+// "SELECT *" ni amb la fila sencera de la taula. Retorna la consulta i els seus paràmetres.
+func scopeQuery(query string, schema *QueryContext, scope dataScope) (string, []interface{}) {
+	tables := append([]TableInfo(nil), schema.Tables...)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	ctes := make([]string, 0, len(tables))
+	for _, table := range tables {
+		columns := make([]string, 0, len(table.Columns))
+		for _, col := range table.Columns {
+			columns = append(columns, fmt.Sprintf("%q", col.Name))
+		}
+		if len(columns) == 0 {
+			columns = append(columns, "NULL AS no_columns")
+		}
+		cte := fmt.Sprintf("%s AS NOT MATERIALIZED (SELECT %s FROM public.%s", table.Name, strings.Join(columns, ", "), table.Name)
+		if rule := customerScopeRules[table.Name]; scope.restricted() && rule.filter != "" {
+			cte += " WHERE " + rule.filter
+		}
+		ctes = append(ctes, cte+")")
+	}
+
+	// Els salts de línia impedeixen que un comentari final de la consulta tanqui el parèntesi
+	wrapped := fmt.Sprintf("WITH %s SELECT * FROM (\n%s\n) AS scoped_result", strings.Join(ctes, ", "), query)
+	if !scope.restricted() {
+		return wrapped, nil
+	}
+	return wrapped, []interface{}{scope.customerID}
+}
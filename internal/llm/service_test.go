@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"orkestra-api/config"
+	"orkestra-api/internal/customers"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// fakeDB és una base de dades en memòria per a database/sql: desa les consultes que rep i
+// respon sempre les mateixes files, o l'error de fail si la consulta conté la clau.
+type fakeDB struct {
+	mu      sync.Mutex
+	queries []string
+	fail    map[string]error
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (d *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{db: d}, nil }
+func (d *fakeDB) Driver() driver.Driver                            { return nil }
+
+func (d *fakeDB) Queries() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.queries...)
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fakeDB: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c, nil
+}
+func (c *fakeConn) Commit() error   { return nil }
+func (c *fakeConn) Rollback() error { return nil }
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, query)
+	for key, err := range c.db.fail {
+		if strings.Contains(query, key) {
+			return nil, err
+		}
+	}
+	return &fakeRows{columns: c.db.columns, rows: c.db.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// fakeRepository només implementa el que fa servir ProcessQuery sense quota ni memòria cau.
+type fakeRepository struct {
+	Repository
+	logs []QueryLog
+}
+
+func (r *fakeRepository) CreateQueryLog(ctx context.Context, entry QueryLog) error {
+	r.logs = append(r.logs, entry)
+	return nil
+}
+
+// fakeCustomerService tracta tots els usuaris com a interns, sense client vinculat.
+type fakeCustomerService struct {
+	customers.CustomerService
+}
+
+func (s fakeCustomerService) FindCustomerByUserID(ctx context.Context, userID string) (customers.Customer, error) {
+	return customers.Customer{}, nil
+}
+
+func newTestService(t *testing.T, db *fakeDB, provider Provider, maxAttempts int) (*Service, *fakeRepository) {
+	t.Helper()
+	conn := sql.OpenDB(db)
+	t.Cleanup(func() { conn.Close() })
+
+	repo := &fakeRepository{}
+	service := NewService(conn, config.Config{LLMSQLMaxAttempts: maxAttempts, LLMMaxRows: 100}, provider, repo, fakeCustomerService{})
+	service.setSchema(buildSchema(&SchemaCatalog{
+		Tables: []CatalogTable{{
+			Name:        "projects",
+			Description: "Projectes",
+			Columns:     map[string]string{"id": "Identificador", "name": "Nom del projecte"},
+		}},
+	}, map[string][]dbColumn{
+		"projects": {{name: "id", dataType: "uuid"}, {name: "name", dataType: "text"}, {name: "estimated_cost", dataType: "numeric"}},
+		"users":    {{name: "id", dataType: "uuid"}, {name: "password", dataType: "text"}},
+	}, nil))
+	return service, repo
+}
+
+func testRequest(question string) QueryRequest {
+	return QueryRequest{UserID: uuid.New(), Question: question, Mode: ModeSQL, ReadCost: true}
+}
+
+func TestProcessQueryAnswersWithGeneratedSQL(t *testing.T) {
+	db := &fakeDB{columns: []string{"name"}, rows: [][]driver.Value{{"Web"}, {"App"}}}
+	provider := NewScriptedProvider(
+		ScriptStep{Contains: "ESQUEMA DE LA BASE DE DADES", Reply: "```sql\nSELECT name FROM projects;\n```"},
+		ScriptStep{Contains: "RESULTATS:", Reply: "Hi ha dos projectes: Web i App."},
+	)
+	service, repo := newTestService(t, db, provider, 3)
+
+	response, err := service.ProcessQuery(context.Background(), testRequest("Quins projectes hi ha?"))
+	if err != nil {
+		t.Fatalf("ProcessQuery: %v", err)
+	}
+	if response.Answer != "Hi ha dos projectes: Web i App." {
+		t.Errorf("answer = %q", response.Answer)
+	}
+	if response.Query != "SELECT name FROM projects" {
+		t.Errorf("query = %q", response.Query)
+	}
+	if rows, ok := response.Data.([]map[string]interface{}); !ok || len(rows) != 2 || rows[0]["name"] != "Web" {
+		t.Errorf("data = %v", response.Data)
+	}
+	if len(response.Attempts) != 1 || response.Attempts[0].Error != "" {
+		t.Errorf("attempts = %+v", response.Attempts)
+	}
+
+	queries := db.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("executed %d queries, want 1", len(queries))
+	}
+	// La consulta només pot veure les columnes del catàleg
+	if !strings.Contains(queries[0], `projects AS NOT MATERIALIZED (SELECT "id", "name" FROM public.projects)`) {
+		t.Errorf("query is not scoped to catalogued columns: %s", queries[0])
+	}
+	if len(repo.logs) != 1 || repo.logs[0].SQLQuery != "SELECT name FROM projects" || repo.logs[0].Error != "" {
+		t.Errorf("query log = %+v", repo.logs)
+	}
+}
+
+func TestProcessQueryRetriesAfterInvalidSQL(t *testing.T) {
+	db := &fakeDB{
+		fail:    map[string]error{"SELECT nom FROM": &pq.Error{Message: `column "nom" does not exist`, Hint: "Perhaps you meant to reference the column \"projects.name\"."}},
+		columns: []string{"name"},
+		rows:    [][]driver.Value{{"Web"}},
+	}
+	provider := NewScriptedProvider(
+		// El segon intent només es respon si el prompt porta l'error del primer
+		ScriptStep{Contains: `column "nom" does not exist`, Reply: "SELECT name FROM projects"},
+		ScriptStep{Contains: "ESQUEMA DE LA BASE DE DADES", Reply: "SELECT nom FROM projects"},
+		ScriptStep{Contains: "RESULTATS:", Reply: "Hi ha un projecte: Web."},
+	)
+	service, _ := newTestService(t, db, provider, 2)
+
+	response, err := service.ProcessQuery(context.Background(), testRequest("Quins projectes hi ha?"))
+	if err != nil {
+		t.Fatalf("ProcessQuery: %v", err)
+	}
+	if response.Query != "SELECT name FROM projects" {
+		t.Errorf("query = %q", response.Query)
+	}
+	if len(response.Attempts) != 2 {
+		t.Fatalf("attempts = %+v", response.Attempts)
+	}
+	if !strings.Contains(response.Attempts[0].Error, `column "nom" does not exist`) || !strings.Contains(response.Attempts[0].Error, "Pista:") {
+		t.Errorf("first attempt error = %q", response.Attempts[0].Error)
+	}
+	if response.Attempts[1].Error != "" || response.Attempts[1].Rows != 1 {
+		t.Errorf("second attempt = %+v", response.Attempts[1])
+	}
+	if prompts := provider.Prompts(); len(prompts) != 3 || !strings.Contains(prompts[1], "INTENTS ANTERIORS QUE HAN FALLAT") {
+		t.Errorf("retry prompt does not include the failed attempt")
+	}
+}
+
+func TestProcessQueryRejectsForbiddenStatement(t *testing.T) {
+	db := &fakeDB{}
+	provider := NewScriptedProvider(
+		ScriptStep{Contains: "ESQUEMA DE LA BASE DE DADES", Reply: "DELETE FROM projects"},
+	)
+	service, repo := newTestService(t, db, provider, 1)
+
+	_, err := service.ProcessQuery(context.Background(), testRequest("Esborra els projectes"))
+	var unsafe *UnsafeQueryError
+	if !errors.As(err, &unsafe) {
+		t.Fatalf("err = %v, want UnsafeQueryError", err)
+	}
+	var attempts *QueryAttemptsError
+	if !errors.As(err, &attempts) || len(attempts.Attempts) != 1 {
+		t.Errorf("err = %v, want one failed attempt", err)
+	}
+	if queries := db.Queries(); len(queries) != 0 {
+		t.Errorf("forbidden statement reached the database: %v", queries)
+	}
+	if len(repo.logs) != 1 || repo.logs[0].Error == "" {
+		t.Errorf("query log = %+v", repo.logs)
+	}
+}
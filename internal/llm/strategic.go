@@ -0,0 +1,324 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"orkestra-api/internal/projects"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// planningData és la fotografia de recursos que es passa al model en mode estratègic.
+type planningData struct {
+	Operators   []map[string]interface{} `json:"operators"`
+	Allocations []map[string]interface{} `json:"allocations"`
+	Projects    []map[string]interface{} `json:"projects"`
+	OpenTasks   []map[string]interface{} `json:"open_tasks"`
+}
+
+// proposedAllocation és el format que demanem al model per a cada assignació proposada.
+type proposedAllocation struct {
+	OperatorID        string      `json:"operator_id"`
+	ProjectID         string      `json:"project_id"`
+	DedicationPercent json.Number `json:"dedication_percentage"`
+	Cost              json.Number `json:"cost"`
+	StartDate         string      `json:"start_date"`
+	EndDate           string      `json:"end_date"`
+}
+
+// resolveMode decideix si la pregunta es respon amb SQL o amb una proposta de planificació.
+// Amb LLM_STRATEGIC_MODE desactivat no es pot demanar el mode estratègic. Els usuaris de
+// client i els perfils sense operators:read_cost tampoc hi tenen accés perquè necessita
+// els costos dels operaris.
+func (s *Service) resolveMode(ctx context.Context, req QueryRequest, scope dataScope) (string, error) {
+	if !s.cfg.LLMStrategicMode {
+		if req.Mode == ModeStrategic {
+			return "", ErrStrategicDisabled
+		}
+		return ModeSQL, nil
+	}
+	if scope.restricted() || !scope.readCost {
+		if req.Mode == ModeStrategic {
+			return "", ErrStrategicNotAllowed
+		}
+		return ModeSQL, nil
+	}
+	switch req.Mode {
+	case ModeSQL, ModeStrategic:
+		return req.Mode, nil
+	}
+	isStrategic, err := s.isStrategicQuestion(ctx, req.Question)
+	if err != nil {
+		return "", err
+	}
+	if isStrategic {
+		return ModeStrategic, nil
+	}
+	return ModeSQL, nil
+}
+
+// --------------------------------------------------
+// Detecció tipus de pregunta
+// --------------------------------------------------
+func (s *Service) isStrategicQuestion(ctx context.Context, question string) (bool, error) {
+	prompt := fmt.Sprintf(`Classifica la següent pregunta com:
+1) SQL: consulta de dades existents (llistats, totals, dates, estats...)
+2) ESTRATÈGICA: planificació o optimització de l'assignació d'operaris a projectes
+
+Pregunta: "%s"
+
+Respon només amb "SQL" o "ESTRATÈGICA".`, question)
+
+	resp, err := s.callLLM(ctx, prompt)
+	if err != nil {
+		return false, err
+	}
+	resp = strings.ToUpper(strings.TrimSpace(resp))
+	// Alguns models responen sense accent o afegeixen puntuació
+	return strings.HasPrefix(resp, "ESTRAT"), nil
+}
+
+// processStrategic respon una pregunta de planificació: recull les dades de recursos,
+// demana una proposta al model i n'extreu les assignacions en format aplicable.
+func (s *Service) processStrategic(ctx context.Context, req QueryRequest, emit StreamEmitter) (*QueryResponse, error) {
+	trace := traceFrom(ctx)
+	start := time.Now()
+	data, err := s.getPlanningData(ctx)
+	trace.stage(stageExecution, start)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relevant data: %w", err)
+	}
+	if err := emit.send(EventRows, map[string]interface{}{"data": data}); err != nil {
+		return nil, err
+	}
+
+	prompt := s.buildStrategicPrompt(req.Question, data)
+	start = time.Now()
+	var answer string
+	if emit == nil {
+		answer, err = s.callLLM(ctx, prompt)
+	} else {
+		answer, err = s.streamLLM(ctx, prompt, func(chunk string) error {
+			return emit.send(EventToken, map[string]interface{}{"text": chunk})
+		})
+	}
+	trace.stage(stageAnswer, start)
+	if err != nil {
+		return nil, fmt.Errorf("error generating strategic answer: %w", err)
+	}
+
+	proposal, warnings := parseAllocationProposal(answer, data)
+	return &QueryResponse{
+		Answer:    answer,
+		Mode:      ModeStrategic,
+		Data:      data,
+		Proposal:  proposal,
+		Warnings:  warnings,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// --------------------------------------------------
+// Recuperació de dades rellevants per preguntes estratègiques
+// --------------------------------------------------
+func (s *Service) getPlanningData(ctx context.Context) (*planningData, error) {
+	var data planningData
+	var err error
+
+	data.Operators, _, err = s.runReadOnly(ctx, `
+		SELECT id, name, surname, cost, color
+		FROM operators
+		ORDER BY name, surname`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assignacions vigents o futures, amb els noms perquè el model les pugui citar
+	data.Allocations, _, err = s.runReadOnly(ctx, `
+		SELECT otp.id, otp.operator_id, o.name || ' ' || o.surname AS operator,
+			otp.project_id, p.description AS project, otp.dedication_percent,
+			otp.start_date, otp.end_date
+		FROM operators_to_projects otp
+			INNER JOIN operators o ON o.id = otp.operator_id
+			INNER JOIN projects p ON p.id = otp.project_id
+		WHERE otp.end_date >= CURRENT_DATE
+		ORDER BY otp.start_date`)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Projects, _, err = s.runReadOnly(ctx, `
+		SELECT p.id, p.description, c.comercial_name AS customer, p.start_date, p.end_date,
+			p.amount, p.estimated_cost
+		FROM projects p
+			INNER JOIN customers c ON c.id = p.customer_id
+		WHERE p.end_date IS NULL OR p.end_date >= CURRENT_DATE
+		ORDER BY p.start_date`)
+	if err != nil {
+		return nil, err
+	}
+
+	data.OpenTasks, _, err = s.runReadOnly(ctx, `
+		SELECT t.id, t.description, t.project_id, t.status, t.priority, t.start_date, t.end_date
+		FROM tasks t
+		WHERE t.status <> 'Done'
+		ORDER BY t.priority, t.end_date NULLS LAST`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// --------------------------------------------------
+// Generació de resposta estratègica
+// --------------------------------------------------
+func (s *Service) buildStrategicPrompt(question string, data *planningData) string {
+	dataJSON, _ := json.MarshalIndent(data, "", "  ")
+	return fmt.Sprintf(`Ets un assistent que ajuda a prendre decisions d'assignació d'operaris a projectes.
+
+Pregunta: %s
+
+Avui és %s.
+
+Dades disponibles:
+- operators: operaris amb el seu cost per hora
+- allocations: assignacions vigents o futures d'operaris a projectes (percentatge de dedicació i dates)
+- projects: projectes actius o futurs amb dates, import (amount) i cost estimat (estimated_cost)
+- open_tasks: tasques pendents, amb prioritat (A la més alta) i dates
+
+%s
+
+Proposa una assignació òptima, ordre d'execució i explicació raonada en català. Tingues en compte que
+la dedicació total d'un operari en un mateix període no hauria de superar el 100%%. Si hi ha limitacions,
+explica-les de manera clara.
+
+Al final de la resposta, afegeix un bloc de codi json amb les assignacions noves que proposes, fent servir
+els identificadors de les dades, amb aquest format exacte:
+`+"```json"+`
+{"allocations": [{"operator_id": "...", "project_id": "...", "dedication_percentage": 50, "start_date": "YYYY-MM-DD", "end_date": "YYYY-MM-DD"}]}
+`+"```"+`
+Si no proposes cap assignació nova, retorna {"allocations": []}.`,
+		question, time.Now().Format("2006-01-02"), string(dataJSON))
+}
+
+// parseAllocationProposal extreu el bloc json de la resposta i el converteix en peticions
+// que el client pot enviar tal qual a POST /api/projects/operators. Les assignacions que
+// no es poden aplicar es descarten i s'expliquen a warnings.
+func parseAllocationProposal(answer string, data *planningData) ([]projects.OperatorToProjectRequest, []string) {
+	block := extractJSONBlock(answer)
+	if block == "" {
+		return nil, []string{"la resposta no inclou cap bloc json amb assignacions"}
+	}
+
+	var parsed struct {
+		Allocations []proposedAllocation `json:"allocations"`
+	}
+	if err := json.Unmarshal([]byte(block), &parsed); err != nil {
+		return nil, []string{fmt.Sprintf("el bloc json de la proposta no és vàlid: %v", err)}
+	}
+
+	operatorCosts := make(map[string]string)
+	for _, op := range data.Operators {
+		operatorCosts[fmt.Sprint(op["id"])] = fmt.Sprint(op["cost"])
+	}
+	knownProjects := make(map[string]struct{})
+	for _, p := range data.Projects {
+		knownProjects[fmt.Sprint(p["id"])] = struct{}{}
+	}
+
+	var proposal []projects.OperatorToProjectRequest
+	var warnings []string
+	for i, item := range parsed.Allocations {
+		request, err := item.toRequest(operatorCosts, knownProjects)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("assignació %d descartada: %v", i+1, err))
+			continue
+		}
+		proposal = append(proposal, request)
+	}
+	return proposal, warnings
+}
+
+func (a proposedAllocation) toRequest(operatorCosts map[string]string, knownProjects map[string]struct{}) (projects.OperatorToProjectRequest, error) {
+	operatorID, err := uuid.Parse(a.OperatorID)
+	if err != nil {
+		return projects.OperatorToProjectRequest{}, fmt.Errorf("operator_id %q no és vàlid", a.OperatorID)
+	}
+	operatorCost, ok := operatorCosts[operatorID.String()]
+	if !ok {
+		return projects.OperatorToProjectRequest{}, fmt.Errorf("l'operari %s no existeix", operatorID)
+	}
+	projectID, err := uuid.Parse(a.ProjectID)
+	if err != nil {
+		return projects.OperatorToProjectRequest{}, fmt.Errorf("project_id %q no és vàlid", a.ProjectID)
+	}
+	if _, ok := knownProjects[projectID.String()]; !ok {
+		return projects.OperatorToProjectRequest{}, fmt.Errorf("el projecte %s no existeix o ja ha acabat", projectID)
+	}
+
+	dedication, err := decimal.NewFromString(a.DedicationPercent.String())
+	if err != nil || dedication.LessThanOrEqual(decimal.Zero) || dedication.GreaterThan(decimal.NewFromInt(100)) {
+		return projects.OperatorToProjectRequest{}, fmt.Errorf("la dedicació %q ha d'estar entre 0 i 100", a.DedicationPercent)
+	}
+
+	// Si el model no indica cost, fem servir el cost de l'operari com fa el formulari
+	cost := operatorCost
+	if a.Cost != "" {
+		cost = a.Cost.String()
+	}
+	if _, err := decimal.NewFromString(cost); err != nil {
+		return projects.OperatorToProjectRequest{}, fmt.Errorf("el cost %q no és vàlid", cost)
+	}
+
+	startDate, err := parseProposalDate(a.StartDate)
+	if err != nil {
+		return projects.OperatorToProjectRequest{}, err
+	}
+	endDate, err := parseProposalDate(a.EndDate)
+	if err != nil {
+		return projects.OperatorToProjectRequest{}, err
+	}
+	if endDate.Before(startDate) {
+		return projects.OperatorToProjectRequest{}, fmt.Errorf("la data de fi és anterior a la d'inici")
+	}
+
+	return projects.OperatorToProjectRequest{
+		OperatorID:        operatorID.String(),
+		ProjectID:         projectID.String(),
+		Cost:              cost,
+		DedicationPercent: dedication.String(),
+		StartDate:         startDate.Format(time.RFC3339),
+		EndDate:           endDate.Format(time.RFC3339),
+	}, nil
+}
+
+// parseProposalDate accepta dates soles o en RFC3339, que és el que espera AddOperator.
+func parseProposalDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("la data %q no és vàlida", value)
+	}
+	return t, nil
+}
+
+// extractJSONBlock retorna el contingut de l'últim bloc ```json de la resposta.
+func extractJSONBlock(response string) string {
+	start := strings.LastIndex(response, "```json")
+	if start == -1 {
+		return ""
+	}
+	start += len("```json")
+	end := strings.Index(response[start:], "```")
+	if end == -1 {
+		return ""
+	}
+	return strings.TrimSpace(response[start : start+end])
+}
@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"context"
+	"orkestra-api/config"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveMode(t *testing.T) {
+	internal := dataScope{readCost: true}
+	tests := []struct {
+		name     string
+		enabled  bool
+		mode     string
+		scope    dataScope
+		classify string
+		want     string
+		wantErr  error
+	}{
+		{name: "disabled defaults to sql", enabled: false, scope: internal, want: ModeSQL},
+		{name: "disabled rejects explicit strategic", enabled: false, mode: ModeStrategic, scope: internal, wantErr: ErrStrategicDisabled},
+		{name: "explicit sql", enabled: true, mode: ModeSQL, scope: internal, want: ModeSQL},
+		{name: "explicit strategic", enabled: true, mode: ModeStrategic, scope: internal, want: ModeStrategic},
+		{name: "strategic without cost permission", enabled: true, mode: ModeStrategic, scope: dataScope{}, wantErr: ErrStrategicNotAllowed},
+		{name: "strategic for a customer", enabled: true, mode: ModeStrategic, scope: dataScope{customerID: uuid.New(), readCost: true}, wantErr: ErrStrategicNotAllowed},
+		{name: "classified as strategic", enabled: true, scope: internal, classify: "ESTRATÈGICA", want: ModeStrategic},
+		{name: "classified as sql", enabled: true, scope: internal, classify: "SQL", want: ModeSQL},
+		{name: "no classification without cost permission", enabled: true, scope: dataScope{}, want: ModeSQL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Sense resposta preparada, qualsevol crida al classificador fa fallar la prova
+			var steps []ScriptStep
+			if tt.classify != "" {
+				steps = append(steps, ScriptStep{Contains: "Classifica la següent pregunta", Reply: tt.classify})
+			}
+			s := &Service{provider: NewScriptedProvider(steps...), cfg: config.Config{LLMStrategicMode: tt.enabled}}
+
+			got, err := s.resolveMode(context.Background(), QueryRequest{Question: "Com repartim els operaris?", Mode: tt.mode}, tt.scope)
+			if err != tt.wantErr {
+				t.Fatalf("resolveMode error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveMode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package llm
+
+// Esdeveniments que s'envien pel flux SSE de /llm/query/stream.
+const (
+	EventSQLGenerated = "sql_generated"
+	EventSQLError     = "sql_error"
+	EventRows         = "rows"
+	EventToken        = "token"
+	EventDone         = "done"
+	EventError        = "error"
+)
+
+// StreamEmitter rep cada etapa del processament d'una pregunta. Si retorna error
+// (per exemple perquè el client s'ha desconnectat) el processament s'atura.
+type StreamEmitter func(event string, data interface{}) error
+
+func (e StreamEmitter) send(event string, data interface{}) error {
+	if e == nil {
+		return nil
+	}
+	return e(event, data)
+}
@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"orkestra-api/config"
+)
+
+// Message és un correu de text pla.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envia correus als usuaris. Hi ha una implementació SMTP i una en memòria
+// per a proves i entorns sense servidor de correu.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewMailer retorna el mailer SMTP si hi ha SMTP_HOST configurat i, si no, el de memòria.
+func NewMailer(cfg config.Config) Mailer {
+	if cfg.SMTPHost == "" {
+		log.Printf("SMTP_HOST not set, emails will not be delivered")
+		return NewMemoryMailer()
+	}
+	return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.MailFrom)
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type smtpMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) Mailer {
+	return &smtpMailer{host: host, port: port, username: username, password: password, from: from}
+}
+
+// Send fa servir STARTTLS si el servidor l'ofereix (smtp.SendMail ho negocia sol).
+func (m *smtpMailer) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	addr := net.JoinHostPort(m.host, m.port)
+	to := strings.TrimSpace(message.To)
+	if err := smtp.SendMail(addr, auth, m.from, []string{to}, m.build(message)); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	return nil
+}
+
+func (m *smtpMailer) build(message Message) []byte {
+	var b strings.Builder
+	// Una adreça amb salts de línia podria afegir capçaleres
+	noNewlines := strings.NewReplacer("\r", "", "\n", "")
+	headers := []string{
+		"From: " + m.from,
+		"To: " + noNewlines.Replace(message.To),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	for _, header := range headers {
+		b.WriteString(header + "\r\n")
+	}
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
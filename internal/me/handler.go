@@ -0,0 +1,65 @@
+package me
+
+import (
+	"errors"
+	"net/http"
+	"orkestra-api/internal/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MeHandler struct {
+	service MeService
+}
+
+func NewMeHandler(service MeService) *MeHandler {
+	return &MeHandler{
+		service: service,
+	}
+}
+
+func (h *MeHandler) Get(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	me, err := h.service.Get(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, me)
+}
+
+func (h *MeHandler) Update(c *gin.Context) {
+	var request users.UpdateMeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	me, err := h.service.Update(c.Request.Context(), userID.(string), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, me)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, users.ErrInvalidRequest), errors.Is(err, users.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, users.ErrUserNotFound), errors.Is(err, users.ErrInactiveUser):
+		return http.StatusNotFound
+	case errors.Is(err, users.ErrPhoneNumberTaken), errors.Is(err, users.ErrEmailTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
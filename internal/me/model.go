@@ -0,0 +1,19 @@
+package me
+
+import (
+	"orkestra-api/internal/customers"
+	"orkestra-api/internal/groups"
+	"orkestra-api/internal/menus"
+	"orkestra-api/internal/profiles"
+	"orkestra-api/internal/users"
+)
+
+// Me és tot el que el frontend necessita de l'usuari que ha fet login.
+// Profile i Customer són nuls si l'usuari no en té.
+type Me struct {
+	User     users.UserResponse  `json:"user"`
+	Profile  *profiles.Profile   `json:"profile"`
+	Groups   []groups.Group      `json:"groups"`
+	Customer *customers.Customer `json:"customer"`
+	Menus    []menus.MenuTree    `json:"menus"`
+}
@@ -0,0 +1,14 @@
+package me
+
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registra les rutes del compte de l'usuari que fa la petició.
+// No cal cap permís: cadascú veu i edita el seu, però sí una sessió d'usuari.
+func RegisterRoutes(router *gin.RouterGroup, handler *MeHandler) {
+	router.GET("/me", middleware.RejectAPITokens, handler.Get)
+	router.PUT("/me", middleware.RejectAPITokens, handler.Update)
+}
@@ -0,0 +1,82 @@
+package me
+
+import (
+	"context"
+	"errors"
+	"orkestra-api/internal/customers"
+	"orkestra-api/internal/groups"
+	"orkestra-api/internal/menus"
+	"orkestra-api/internal/profiles"
+	"orkestra-api/internal/users"
+
+	"github.com/google/uuid"
+)
+
+type MeService interface {
+	Get(ctx context.Context, userID string) (Me, error)
+	Update(ctx context.Context, userID string, request users.UpdateMeRequest) (Me, error)
+}
+
+type meService struct {
+	userService     users.UserService
+	profileService  profiles.ProfileService
+	groupService    groups.GroupService
+	customerService customers.CustomerService
+}
+
+func NewMeService(userService users.UserService, profileService profiles.ProfileService, groupService groups.GroupService, customerService customers.CustomerService) MeService {
+	return &meService{
+		userService:     userService,
+		profileService:  profileService,
+		groupService:    groupService,
+		customerService: customerService,
+	}
+}
+
+func (s *meService) Get(ctx context.Context, userID string) (Me, error) {
+	user, err := s.userService.FindByID(ctx, userID)
+	if err != nil {
+		return Me{}, err
+	}
+	return s.build(ctx, user)
+}
+
+func (s *meService) Update(ctx context.Context, userID string, request users.UpdateMeRequest) (Me, error) {
+	user, err := s.userService.UpdateMe(ctx, userID, request)
+	if err != nil {
+		return Me{}, err
+	}
+	return s.build(ctx, user)
+}
+
+// build afegeix a l'usuari el perfil amb els menús, els grups i el client
+func (s *meService) build(ctx context.Context, user users.UserResponse) (Me, error) {
+	me := Me{User: user, Groups: []groups.Group{}, Menus: []menus.MenuTree{}}
+
+	profile, err := s.profileService.FindByID(ctx, user.ProfileID)
+	switch {
+	case err == nil:
+		me.Profile = &profile.Profile
+		me.Menus = profile.Menus
+	case !errors.Is(err, profiles.ErrProfileNotFound) && !errors.Is(err, profiles.ErrInvalidProfileID):
+		return Me{}, err
+	}
+
+	userGroups, err := s.groupService.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return Me{}, err
+	}
+	if userGroups != nil {
+		me.Groups = userGroups
+	}
+
+	// FindCustomerByUserID retorna un client buit si l'usuari no en té cap
+	customer, err := s.customerService.FindCustomerByUserID(ctx, user.ID)
+	if err != nil {
+		return Me{}, err
+	}
+	if customer.ID != uuid.Nil {
+		me.Customer = &customer
+	}
+	return me, nil
+}
@@ -0,0 +1,105 @@
+package meetings
+
+import (
+	"context"
+	"orkestra-api/internal/groups"
+	"orkestra-api/internal/tasks"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAgreementTask crea la tasca de seguiment d'un acord. El caller ha de ser membre del
+// grup i la persona assignada també.
+func (s *meetingService) CreateAgreementTask(ctx context.Context, caller groups.Caller, id string, request AgreementTaskRequest) (tasks.Task, error) {
+	agreementID, err := uuid.Parse(id)
+	if err != nil {
+		return tasks.Task{}, ErrInvalidID
+	}
+	userID, err := uuid.Parse(request.UserID)
+	if err != nil {
+		return tasks.Task{}, ErrInvalidID
+	}
+	groupID, err := s.meetingRepository.FindGroupIDByAgreementID(ctx, agreementID)
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, false); err != nil {
+		return tasks.Task{}, err
+	}
+	membership, err := s.groupService.Membership(ctx, groupID, userID)
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	if !membership.Member {
+		return tasks.Task{}, ErrAssigneeNotInGroup
+	}
+
+	description := request.Description
+	if description == "" {
+		description, err = s.meetingRepository.FindAgreementTitle(ctx, agreementID)
+		if err != nil {
+			return tasks.Task{}, err
+		}
+	}
+	return s.taskService.CreateForAgreement(ctx, agreementID, &tasks.TaskRequest{
+		Description: description,
+		Notes:       request.Notes,
+		UserID:      request.UserID,
+		Status:      tasks.StatusTodo,
+		Priority:    tasks.TaskPriority(request.Priority),
+		ProjectID:   request.ProjectID,
+		StartDate:   request.StartDate,
+		EndDate:     request.EndDate,
+	})
+}
+
+// FindActionItems retorna les tasques obertes de la reunió. Qualsevol membre del grup les
+// pot consultar.
+func (s *meetingService) FindActionItems(ctx context.Context, caller groups.Caller, id string) ([]ActionItem, error) {
+	meetingID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if err := s.authorizeMeeting(ctx, caller, meetingID, false); err != nil {
+		return nil, err
+	}
+	return s.meetingRepository.FindActionItems(ctx, meetingID, string(tasks.StatusDone))
+}
+
+// CarryOverActionItems traspassa les tasques obertes de la reunió a la reunió següent del
+// grup, que es crea primer si és una ocurrència d'una sèrie que encara no existeix.
+func (s *meetingService) CarryOverActionItems(ctx context.Context, caller groups.Caller, id string) (CarryOver, error) {
+	meetingID, err := uuid.Parse(id)
+	if err != nil {
+		return CarryOver{}, ErrInvalidID
+	}
+	if err := s.authorizeMeeting(ctx, caller, meetingID, false); err != nil {
+		return CarryOver{}, err
+	}
+	meeting, err := s.meetingRepository.FindByID(ctx, meetingID)
+	if err != nil {
+		return CarryOver{}, err
+	}
+
+	until := time.Now().Add(seriesHorizon)
+	if startTime, err := time.Parse(time.RFC3339Nano, meeting.StartTime); err == nil && startTime.Add(seriesHorizon).After(until) {
+		until = startTime.Add(seriesHorizon)
+	}
+	if err := s.materialize(ctx, meeting.GroupID, uuid.Nil, until); err != nil {
+		return CarryOver{}, err
+	}
+
+	nextID, err := s.meetingRepository.FindNextMeetingID(ctx, meetingID)
+	if err != nil {
+		return CarryOver{}, err
+	}
+	if err := s.meetingRepository.CarryOverActionItems(ctx, meetingID, nextID, string(tasks.StatusDone)); err != nil {
+		return CarryOver{}, err
+	}
+	items, err := s.meetingRepository.FindActionItems(ctx, nextID, string(tasks.StatusDone))
+	if err != nil {
+		return CarryOver{}, err
+	}
+	return CarryOver{MeetingID: nextID, ActionItems: items}, nil
+}
@@ -0,0 +1,100 @@
+package meetings
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// actionItemsFilter selecciona les tasques d'una reunió ($1): les dels seus acords i les que
+// s'hi han traspassat. Les tasques acabades ($2) no hi compten.
+const actionItemsFilter = `
+	tk.status <> $2 AND (
+		tk.agreement_id IN (
+			SELECT a.id
+			FROM meeting_topic_agreements a
+				INNER JOIN meeting_topics t ON t.id = a.meeting_topic_id
+			WHERE t.meeting_id = $1)
+		OR tk.id IN (SELECT task_id FROM meeting_carried_tasks WHERE meeting_id = $1))`
+
+func (r *meetingRepository) FindAgreementTitle(ctx context.Context, id uuid.UUID) (string, error) {
+	var title string
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(title, '') FROM meeting_topic_agreements WHERE id = $1`, id).Scan(&title)
+	if err == sql.ErrNoRows {
+		return "", ErrMeetingNotFound
+	}
+	return title, err
+}
+
+// FindActionItems retorna les tasques obertes de la reunió, primer les que tenen la data
+// límit més propera.
+func (r *meetingRepository) FindActionItems(ctx context.Context, meetingID uuid.UUID, doneStatus string) ([]ActionItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tk.id, tk.description, tk.status, tk.priority, tk.user_id, COALESCE(TRIM(u.name || ' ' || u.surname), ''),
+			tk.end_date, tk.project_id, tk.agreement_id, COALESCE(a.title, ''), COALESCE(t.title, ''), t.meeting_id
+		FROM tasks tk
+			LEFT JOIN users u ON u.id = tk.user_id
+			LEFT JOIN meeting_topic_agreements a ON a.id = tk.agreement_id
+			LEFT JOIN meeting_topics t ON t.id = a.meeting_topic_id
+		WHERE `+actionItemsFilter+`
+		ORDER BY tk.end_date NULLS LAST, tk.priority, tk.description, tk.id`, meetingID, doneStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ActionItem{}
+	for rows.Next() {
+		var item ActionItem
+		var endDate sql.NullTime
+		var projectID, agreementID, originID uuid.NullUUID
+		if err := rows.Scan(&item.TaskID, &item.Description, &item.Status, &item.Priority, &item.UserID, &item.Assignee,
+			&endDate, &projectID, &agreementID, &item.AgreementTitle, &item.TopicTitle, &originID); err != nil {
+			return nil, err
+		}
+		if endDate.Valid {
+			item.EndDate = &endDate.Time
+		}
+		if projectID.Valid {
+			item.ProjectID = &projectID.UUID
+		}
+		if agreementID.Valid {
+			item.AgreementID = &agreementID.UUID
+		}
+		if originID.Valid {
+			item.MeetingID = &originID.UUID
+		}
+		item.Carried = !originID.Valid || originID.UUID != meetingID
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// FindNextMeetingID retorna la primera reunió del mateix grup que comença després d'aquesta.
+func (r *meetingRepository) FindNextMeetingID(ctx context.Context, meetingID uuid.UUID) (uuid.UUID, error) {
+	var nextID uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+		SELECT n.id
+		FROM meetings m
+			INNER JOIN meetings n ON n.group_id = m.group_id AND n.start_time > m.start_time
+		WHERE m.id = $1
+		ORDER BY n.start_time, n.id
+		LIMIT 1`, meetingID).Scan(&nextID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrNoNextMeeting
+	}
+	return nextID, err
+}
+
+// CarryOverActionItems afegeix les tasques obertes de from a la reunió to. Les que ja hi
+// són es deixen com estan.
+func (r *meetingRepository) CarryOverActionItems(ctx context.Context, from, to uuid.UUID, doneStatus string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO meeting_carried_tasks (meeting_id, task_id, carried_from)
+		SELECT $3, tk.id, $1
+		FROM tasks tk
+		WHERE `+actionItemsFilter+`
+		ON CONFLICT (meeting_id, task_id) DO NOTHING`, from, doneStatus, to)
+	return err
+}
@@ -5,13 +5,43 @@ type CreateMeetingRequest struct {
 	Title       string `json:"title" binding:"required"`
 	Description string `json:"description" binding:"required"`
 	StartTime   string `json:"start_time" binding:"required"`
-	CreatedBy   string `json:"created_by" binding:"required"`
+	// S'ignora: la reunió la crea l'usuari de la petició
+	CreatedBy string `json:"created_by"`
 }
 type UpdateMeetingRequest struct {
 	GroupID     string `json:"group_id" binding:"required"`
 	Title       string `json:"title" binding:"required"`
 	Description string `json:"description" binding:"required"`
 	StartTime   string `json:"start_time" binding:"required"`
+	// Scope només compta per a les ocurrències d'una sèrie: "this" (per defecte) o "following"
+	Scope string `json:"scope" binding:"omitempty,oneof=this following"`
+	// RRule canvia la regla de les ocurrències següents. Només amb scope "following"
+	RRule string `json:"rrule"`
+}
+
+// Abast de l'edició o l'esborrat d'una ocurrència d'una sèrie.
+const (
+	ScopeThis      = "this"
+	ScopeFollowing = "following"
+)
+
+// CreateMeetingSeriesRequest crea una sèrie. StartTime (RFC 3339) és l'hora de la primera
+// reunió i RRule la regla, p. ex. "FREQ=WEEKLY;BYDAY=MO;COUNT=10".
+type CreateMeetingSeriesRequest struct {
+	GroupID        string   `json:"group_id" binding:"required"`
+	Title          string   `json:"title" binding:"required,max=250"`
+	Description    string   `json:"description"`
+	StartTime      string   `json:"start_time" binding:"required"`
+	RRule          string   `json:"rrule" binding:"required"`
+	ParticipantIDs []string `json:"participant_ids"`
+	Topics         []string `json:"topics"`
+}
+
+// SeriesTemplateRequest canvia els participants i els temes de les ocurrències que encara
+// no s'han creat.
+type SeriesTemplateRequest struct {
+	ParticipantIDs []string `json:"participant_ids"`
+	Topics         []string `json:"topics"`
 }
 type MeetingParticipantRequest struct {
 	MeetingID string `json:"meeting_id" binding:"required"`
@@ -25,5 +55,18 @@ type MeetingTopicsRequest struct {
 type MeetingTopicAgreementRequest struct {
 	MeetingTopicId string `json:"meeting_topic_id" binding:"required"`
 	Title          string `json:"title" binding:"required"`
-	CreatedBy      string `json:"created_by" binding:"required"`
+	// S'ignora: l'acord el crea l'usuari de la petició
+	CreatedBy string `json:"created_by"`
+}
+
+// AgreementTaskRequest crea la tasca de seguiment d'un acord. Sense descripció es fa servir
+// el títol de l'acord. EndDate (RFC 3339) és la data límit.
+type AgreementTaskRequest struct {
+	UserID      string  `json:"user_id" binding:"required"`
+	Priority    string  `json:"priority" binding:"required,oneof=A B C D"`
+	Description string  `json:"description" binding:"max=255"`
+	Notes       string  `json:"notes"`
+	ProjectID   string  `json:"project_id"`
+	StartDate   *string `json:"start_date"`
+	EndDate     *string `json:"end_date"`
 }
@@ -3,8 +3,17 @@ package meetings
 import "errors"
 
 var (
-	ErrInvalidID            = errors.New("invalid meeting ID")
-	ErrInvalidRequest       = errors.New("invalid request")
-	ErrMeetingNotFound      = errors.New("meeting not found")
-	ErrMeetingAlreadyExists = errors.New("meeting already exists")
-)
\ No newline at end of file
+	ErrInvalidID             = errors.New("invalid meeting ID")
+	ErrInvalidRequest        = errors.New("invalid request")
+	ErrMeetingNotFound       = errors.New("meeting not found")
+	ErrMeetingAlreadyExists  = errors.New("meeting already exists")
+	ErrParticipantNotInGroup = errors.New("participant is not a member of the meeting's group")
+	ErrSeriesNotFound        = errors.New("meeting series not found")
+	ErrInvalidRecurrence     = errors.New("invalid recurrence rule")
+	ErrInvalidScope          = errors.New("scope must be this or following")
+	ErrNotRecurring          = errors.New("meeting is not part of a series")
+	ErrRuleMismatch          = errors.New("start_time does not match the series rule, send a new rrule")
+	ErrInvalidFormat         = errors.New("format must be md, html or pdf")
+	ErrAssigneeNotInGroup    = errors.New("assignee is not a member of the meeting's group")
+	ErrNoNextMeeting         = errors.New("the group has no later meeting")
+)
@@ -1,18 +1,26 @@
 package meetings
 
 import (
+	"errors"
 	"net/http"
+	"orkestra-api/internal/groups"
+	"orkestra-api/internal/ical"
+	"orkestra-api/internal/projects"
+	"orkestra-api/internal/tasks"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MeetingHandler struct {
 	MeetingService MeetingService
+	Minutes        *MinutesRenderer
 }
 
-func NewMeetingHandler(meetingService MeetingService) *MeetingHandler {
+func NewMeetingHandler(meetingService MeetingService, minutes *MinutesRenderer) *MeetingHandler {
 	return &MeetingHandler{
 		MeetingService: meetingService,
+		Minutes:        minutes,
 	}
 }
 
@@ -23,9 +31,14 @@ func (h *MeetingHandler) CreateMeeting(c *gin.Context) {
 		return
 	}
 
-	meeting, err := h.MeetingService.Create(c.Request.Context(), request)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	meeting, err := h.MeetingService.Create(c.Request.Context(), caller, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -40,9 +53,14 @@ func (h *MeetingHandler) UpdateMeeting(c *gin.Context) {
 		return
 	}
 
-	meeting, err := h.MeetingService.Update(c.Request.Context(), id, request)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	meeting, err := h.MeetingService.Update(c.Request.Context(), caller, id, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -51,9 +69,14 @@ func (h *MeetingHandler) UpdateMeeting(c *gin.Context) {
 
 func (h *MeetingHandler) DeleteMeeting(c *gin.Context) {
 	id := c.Param("id")
-	err := h.MeetingService.Delete(c.Request.Context(), id)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.MeetingService.Delete(c.Request.Context(), caller, id, c.Query("scope"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -62,9 +85,14 @@ func (h *MeetingHandler) DeleteMeeting(c *gin.Context) {
 
 func (h *MeetingHandler) GetMeetingByID(c *gin.Context) {
 	id := c.Param("id")
-	meeting, err := h.MeetingService.FindByID(c.Request.Context(), id)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	meeting, err := h.MeetingService.FindByID(c.Request.Context(), caller, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -73,9 +101,14 @@ func (h *MeetingHandler) GetMeetingByID(c *gin.Context) {
 
 func (h *MeetingHandler) GetMeetingsByGroupID(c *gin.Context) {
 	id := c.Param("id")
-	meetings, err := h.MeetingService.FindByGroupID(c.Request.Context(), id)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	meetings, err := h.MeetingService.FindByGroupID(c.Request.Context(), caller, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -92,12 +125,21 @@ func (h *MeetingHandler) GetMeetingsBetweenDates(c *gin.Context) {
 		return
 	}
 
-	meetings, err := h.MeetingService.FindBetweenDates(c.Request.Context(), startDate, endDate, groupID)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	meetings, err := h.MeetingService.FindBetweenDates(c.Request.Context(), caller, startDate, endDate, groupID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	if c.Query("format") == "ics" {
+		ical.Render(c, "meetings.ics", ical.Calendar{Name: "Orkestra - Reunions", Events: CalendarEvents(meetings)})
+		return
+	}
 	c.JSON(http.StatusOK, meetings)
 }
 
@@ -108,9 +150,14 @@ func (h *MeetingHandler) AddParticipant(c *gin.Context) {
 		return
 	}
 
-	err := h.MeetingService.AddParticipant(c.Request.Context(), request)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.MeetingService.AddParticipant(c.Request.Context(), caller, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -119,9 +166,14 @@ func (h *MeetingHandler) AddParticipant(c *gin.Context) {
 
 func (h *MeetingHandler) RemoveParticipant(c *gin.Context) {
 	id := c.Param("id")
-	err := h.MeetingService.RemoveParticipant(c.Request.Context(), id)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.MeetingService.RemoveParticipant(c.Request.Context(), caller, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
@@ -134,9 +186,14 @@ func (h *MeetingHandler) AddTopics(c *gin.Context) {
 		return
 	}
 
-	topic, err := h.MeetingService.AddTopics(c.Request.Context(), request)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	topic, err := h.MeetingService.AddTopics(c.Request.Context(), caller, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -145,9 +202,14 @@ func (h *MeetingHandler) AddTopics(c *gin.Context) {
 
 func (h *MeetingHandler) RemoveTopics(c *gin.Context) {
 	id := c.Param("id")
-	err := h.MeetingService.RemoveTopics(c.Request.Context(), id)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.MeetingService.RemoveTopics(c.Request.Context(), caller, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
@@ -160,9 +222,14 @@ func (h *MeetingHandler) AddTopicAgreements(c *gin.Context) {
 		return
 	}
 
-	topicAgreement, err := h.MeetingService.AddTopicAgreements(c.Request.Context(), request)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	topicAgreement, err := h.MeetingService.AddTopicAgreements(c.Request.Context(), caller, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -177,9 +244,14 @@ func (h *MeetingHandler) UpdateTopicAgreements(c *gin.Context) {
 		return
 	}
 
-	topicAgreement, err := h.MeetingService.UpdateTopicAgreements(c.Request.Context(), id, request)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	topicAgreement, err := h.MeetingService.UpdateTopicAgreements(c.Request.Context(), caller, id, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -188,10 +260,176 @@ func (h *MeetingHandler) UpdateTopicAgreements(c *gin.Context) {
 
 func (h *MeetingHandler) RemoveTopicAgreements(c *gin.Context) {
 	id := c.Param("id")
-	err := h.MeetingService.RemoveTopicAgreements(c.Request.Context(), id)
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	err := h.MeetingService.RemoveTopicAgreements(c.Request.Context(), caller, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
-}
\ No newline at end of file
+}
+
+func (h *MeetingHandler) CreateMeetingSeries(c *gin.Context) {
+	var request CreateMeetingSeriesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	series, err := h.MeetingService.CreateSeries(c.Request.Context(), caller, request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+func (h *MeetingHandler) GetMeetingSeries(c *gin.Context) {
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	series, err := h.MeetingService.FindSeriesByID(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *MeetingHandler) UpdateMeetingSeriesTemplate(c *gin.Context) {
+	var request SeriesTemplateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	series, err := h.MeetingService.UpdateSeriesTemplate(c.Request.Context(), caller, c.Param("id"), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetMeetingMinutes retorna l'acta de la reunió en Markdown (per defecte), HTML o PDF.
+func (h *MeetingHandler) GetMeetingMinutes(c *gin.Context) {
+	format := c.DefaultQuery("format", MinutesMarkdown)
+	if format != MinutesMarkdown && format != MinutesHTML && format != MinutesPDF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidFormat.Error()})
+		return
+	}
+
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	minutes, err := h.MeetingService.FindMinutes(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	body, contentType, err := h.Minutes.Render(format, minutes)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	disposition := "attachment"
+	if format == MinutesHTML {
+		disposition = "inline"
+	}
+	filename := "acta-" + minutes.StartTime.In(time.Local).Format("2006-01-02") + "." + format
+	c.Header("Content-Disposition", disposition+`; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// CreateAgreementTask crea la tasca de seguiment d'un acord.
+func (h *MeetingHandler) CreateAgreementTask(c *gin.Context) {
+	var request AgreementTaskRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	task, err := h.MeetingService.CreateAgreementTask(c.Request.Context(), caller, c.Param("id"), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+func (h *MeetingHandler) GetActionItems(c *gin.Context) {
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	items, err := h.MeetingService.FindActionItems(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// CarryOverActionItems traspassa les tasques obertes a la reunió següent del grup.
+func (h *MeetingHandler) CarryOverActionItems(c *gin.Context) {
+	caller, ok := groups.CallerFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	result, err := h.MeetingService.CarryOverActionItems(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrParticipantNotInGroup),
+		errors.Is(err, ErrInvalidRecurrence), errors.Is(err, ErrInvalidScope), errors.Is(err, ErrNotRecurring), errors.Is(err, ErrRuleMismatch),
+		errors.Is(err, ErrInvalidFormat), errors.Is(err, ErrAssigneeNotInGroup),
+		errors.Is(err, tasks.ErrInvalidID), errors.Is(err, tasks.ErrInvalidRequest), errors.Is(err, tasks.ErrInvalidStatus),
+		errors.Is(err, tasks.ErrInvalidPriority), errors.Is(err, tasks.ErrInvalidDate):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrMeetingNotFound), errors.Is(err, ErrSeriesNotFound), errors.Is(err, ErrNoNextMeeting),
+		errors.Is(err, projects.ErrProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tasks.ErrAgreementHasTask):
+		return http.StatusConflict
+	default:
+		return groups.ErrorStatus(err)
+	}
+}
@@ -0,0 +1,30 @@
+package meetings
+
+import (
+	"orkestra-api/internal/ical"
+	"time"
+)
+
+// meetingDuration és la durada que es publica: les reunions només tenen hora d'inici.
+const meetingDuration = time.Hour
+
+// CalendarEvents converteix les reunions en esdeveniments iCalendar. Les reunions amb
+// una hora d'inici que no es pot interpretar no es publiquen.
+func CalendarEvents(meetings []MeetingSummary) []ical.Event {
+	events := make([]ical.Event, 0, len(meetings))
+	for _, meeting := range meetings {
+		start, err := ical.ParseTime(meeting.StartTime)
+		if err != nil {
+			continue
+		}
+		events = append(events, ical.Event{
+			UID:         "meeting-" + meeting.ID.String() + "@orkestra",
+			Summary:     meeting.Title,
+			Description: "Grup: " + meeting.GroupName,
+			Categories:  []string{meeting.GroupName},
+			Start:       start,
+			End:         start.Add(meetingDuration),
+		})
+	}
+	return events
+}
@@ -0,0 +1,106 @@
+package meetings
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"orkestra-api/internal/pdf"
+	"os"
+	"path/filepath"
+	texttemplate "text/template"
+	"time"
+)
+
+// Formats de l'acta.
+const (
+	MinutesMarkdown = "md"
+	MinutesHTML     = "html"
+	MinutesPDF      = "pdf"
+)
+
+const (
+	markdownTemplate = "minutes.md.tmpl"
+	htmlTemplate     = "minutes.html.tmpl"
+)
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+var minutesFuncs = map[string]any{
+	"date": func(t time.Time) string { return t.In(time.Local).Format("02/01/2006 15:04") },
+	"inc":  func(i int) int { return i + 1 },
+}
+
+// MinutesRenderer genera l'acta a partir de les plantilles. El PDF surt de la plantilla
+// Markdown, de manera que personalitzar-la també canvia el PDF.
+type MinutesRenderer struct {
+	markdown *texttemplate.Template
+	html     *htmltemplate.Template
+}
+
+// NewMinutesRenderer carrega les plantilles incrustades. Si dir no és buit, les plantilles
+// que hi hagi amb el mateix nom (minutes.md.tmpl, minutes.html.tmpl) les substitueixen.
+func NewMinutesRenderer(dir string) (*MinutesRenderer, error) {
+	markdownSource, err := readTemplate(dir, markdownTemplate)
+	if err != nil {
+		return nil, err
+	}
+	htmlSource, err := readTemplate(dir, htmlTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	markdown, err := texttemplate.New(markdownTemplate).Funcs(minutesFuncs).Parse(markdownSource)
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New(htmlTemplate).Funcs(minutesFuncs).Parse(htmlSource)
+	if err != nil {
+		return nil, err
+	}
+	return &MinutesRenderer{markdown: markdown, html: html}, nil
+}
+
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(content), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	content, err := embeddedTemplates.ReadFile("templates/" + name)
+	return string(content), err
+}
+
+// Render retorna l'acta en el format demanat i el seu Content-Type.
+func (r *MinutesRenderer) Render(format string, minutes Minutes) ([]byte, string, error) {
+	var out bytes.Buffer
+	switch format {
+	case MinutesMarkdown:
+		if err := r.markdown.Execute(&out, minutes); err != nil {
+			return nil, "", err
+		}
+		return out.Bytes(), "text/markdown; charset=utf-8", nil
+	case MinutesHTML:
+		if err := r.html.Execute(&out, minutes); err != nil {
+			return nil, "", err
+		}
+		return out.Bytes(), "text/html; charset=utf-8", nil
+	case MinutesPDF:
+		var markdown bytes.Buffer
+		if err := r.markdown.Execute(&markdown, minutes); err != nil {
+			return nil, "", err
+		}
+		if _, err := pdf.FromMarkdown(markdown.String()).WriteTo(&out); err != nil {
+			return nil, "", err
+		}
+		return out.Bytes(), "application/pdf", nil
+	default:
+		return nil, "", ErrInvalidFormat
+	}
+}
@@ -0,0 +1,217 @@
+package meetings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMinutes(participants int) Minutes {
+	minutes := Minutes{
+		Title:       "Revisió (trimestral) del pressupost",
+		Description: `Documents a \\servidor\actes i a l'àrea comuna`,
+		GroupName:   "Direcció",
+		StartTime:   time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local),
+		Topics: []MinutesTopic{
+			{Title: "Despeses", Agreements: []MinutesAgreement{
+				{Title: "Reduir el cost (fase 1)", Author: "Núria"},
+				{Title: `Arxivar a C:\actes`},
+			}},
+			{Title: "Torn obert"},
+		},
+	}
+	for i := 1; i <= participants; i++ {
+		minutes.Participants = append(minutes.Participants, fmt.Sprintf("Participant %d Martí", i))
+	}
+	return minutes
+}
+
+var pdfPageTree = regexp.MustCompile(`/Type /Pages /Kids \[([^\]]*)\] /Count (\d+)`)
+
+// pdfPages comprova que cada entrada de la taula xref apunta a "N 0 obj" i que l'arbre de
+// pàgines és coherent, i retorna el contingut de cada pàgina.
+func pdfPages(t *testing.T, data []byte) []string {
+	t.Helper()
+	trailer := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if trailer == nil {
+		t.Fatalf("missing startxref trailer")
+	}
+	xref, _ := strconv.Atoi(string(trailer[1]))
+	lines := strings.Split(string(data[xref:]), "\n")
+	if lines[0] != "xref" {
+		t.Fatalf("startxref %d points to %q", xref, lines[0])
+	}
+	size, _ := strconv.Atoi(strings.TrimPrefix(lines[1], "0 "))
+	objects := make(map[int]string)
+	for n := 1; n < size; n++ {
+		offset, _ := strconv.Atoi(lines[2+n][:10])
+		header := fmt.Sprintf("%d 0 obj\n", n)
+		if !bytes.HasPrefix(data[offset:], []byte(header)) {
+			t.Fatalf("xref entry %d points to %q", n, data[offset:offset+12])
+		}
+		body := string(data[offset+len(header):])
+		objects[n] = body[:strings.Index(body, "\nendobj\n")]
+	}
+
+	tree := pdfPageTree.FindStringSubmatch(objects[2])
+	if tree == nil {
+		t.Fatalf("object 2 is not the page tree: %q", objects[2])
+	}
+	kids := strings.Fields(strings.ReplaceAll(tree[1], " 0 R", ""))
+	if count, _ := strconv.Atoi(tree[2]); count != len(kids) {
+		t.Errorf("page tree /Count = %d, but it has %d kids", count, len(kids))
+	}
+	var pages []string
+	for _, kid := range kids {
+		n, _ := strconv.Atoi(kid)
+		contents := regexp.MustCompile(`/Contents (\d+) 0 R`).FindStringSubmatch(objects[n])
+		if !strings.HasPrefix(objects[n], "<< /Type /Page ") || contents == nil {
+			t.Fatalf("kid %d is not a page: %q", n, objects[n])
+		}
+		c, _ := strconv.Atoi(contents[1])
+		pages = append(pages, objects[c])
+	}
+	if want := 5 + 2*len(pages); size != want {
+		t.Errorf("xref has %d entries, want %d for %d pages", size, want, len(pages))
+	}
+	return pages
+}
+
+func TestRenderMinutesPDF(t *testing.T) {
+	renderer, err := NewMinutesRenderer("")
+	if err != nil {
+		t.Fatalf("NewMinutesRenderer: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		participants int
+		pages        int
+	}{
+		{name: "single page", participants: 3, pages: 1},
+		// Cada participant és una línia de 10.5 punts més l'espai abans de l'element
+		{name: "several pages", participants: 100, pages: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, contentType, err := renderer.Render(MinutesPDF, testMinutes(test.participants))
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if contentType != "application/pdf" {
+				t.Errorf("content type = %q", contentType)
+			}
+			pages := pdfPages(t, data)
+			if len(pages) != test.pages {
+				t.Fatalf("pages = %d, want %d", len(pages), test.pages)
+			}
+
+			first, last := pages[0], pages[len(pages)-1]
+			for _, want := range []string{
+				"(Acta: Revisi\xf3 \\(trimestral\\) del pressupost) Tj",
+				"(Documents a \\\\\\\\servidor\\\\actes i a l'\xe0rea comuna) Tj",
+				"(Participant 1 Mart\xed) Tj",
+			} {
+				if !strings.Contains(first, want) {
+					t.Errorf("first page does not contain %q", want)
+				}
+			}
+			for _, want := range []string{
+				fmt.Sprintf("(Participant %d Mart\xed) Tj", test.participants),
+				"(Reduir el cost \\(fase 1\\) \\(N\xfaria\\)) Tj",
+				"(Arxivar a C:\\\\actes) Tj",
+				"(Sense acords.) Tj",
+			} {
+				if !strings.Contains(last, want) {
+					t.Errorf("last page does not contain %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderMinutesFormats(t *testing.T) {
+	renderer, err := NewMinutesRenderer("")
+	if err != nil {
+		t.Fatalf("NewMinutesRenderer: %v", err)
+	}
+	minutes := testMinutes(1)
+
+	markdown, contentType, err := renderer.Render(MinutesMarkdown, minutes)
+	if err != nil || contentType != "text/markdown; charset=utf-8" {
+		t.Fatalf("Render markdown: %q, %v", contentType, err)
+	}
+	if !bytes.Contains(markdown, []byte("- Reduir el cost (fase 1) (Núria)\n")) {
+		t.Errorf("markdown = %s", markdown)
+	}
+
+	html, contentType, err := renderer.Render(MinutesHTML, minutes)
+	if err != nil || contentType != "text/html; charset=utf-8" {
+		t.Fatalf("Render html: %q, %v", contentType, err)
+	}
+	if !bytes.Contains(html, []byte("l&#39;àrea")) {
+		t.Errorf("html is not escaped: %s", html)
+	}
+
+	if _, _, err := renderer.Render("docx", minutes); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("err = %v, want ErrInvalidFormat", err)
+	}
+}
+
+func TestMinutesTemplateOverride(t *testing.T) {
+	// Només se substitueix la plantilla Markdown: la HTML continua sent la incrustada
+	dir := t.TempDir()
+	custom := "# Acta personalitzada: {{ .Title }}\n\nRedactada el {{ date .StartTime }}\n"
+	if err := os.WriteFile(filepath.Join(dir, markdownTemplate), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	renderer, err := NewMinutesRenderer(dir)
+	if err != nil {
+		t.Fatalf("NewMinutesRenderer: %v", err)
+	}
+	minutes := testMinutes(2)
+
+	markdown, _, err := renderer.Render(MinutesMarkdown, minutes)
+	if err != nil {
+		t.Fatalf("Render markdown: %v", err)
+	}
+	want := "# Acta personalitzada: Revisió (trimestral) del pressupost\n\nRedactada el " + minutes.StartTime.Format("02/01/2006 15:04") + "\n"
+	if string(markdown) != want {
+		t.Errorf("markdown = %q, want %q", markdown, want)
+	}
+
+	// El PDF surt de la plantilla Markdown, així que també canvia
+	data, _, err := renderer.Render(MinutesPDF, minutes)
+	if err != nil {
+		t.Fatalf("Render pdf: %v", err)
+	}
+	pages := pdfPages(t, data)
+	if len(pages) != 1 || !strings.Contains(pages[0], "(Acta personalitzada: ") || strings.Contains(pages[0], "(Participants) Tj") {
+		t.Errorf("pdf does not use the override template: %q", pages)
+	}
+
+	embedded, err := NewMinutesRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overridden, _, _ := renderer.Render(MinutesHTML, minutes)
+	original, _, _ := embedded.Render(MinutesHTML, minutes)
+	if !bytes.Equal(overridden, original) {
+		t.Errorf("html template without override changed")
+	}
+
+	// Una plantilla que no compila fa fallar la creació
+	if err := os.WriteFile(filepath.Join(dir, htmlTemplate), []byte("{{ .Title "), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMinutesRenderer(dir); err == nil {
+		t.Errorf("invalid override template was accepted")
+	}
+}
@@ -1,6 +1,10 @@
 package meetings
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Topic struct {
 	ID              string            `json:"id" db:"id"`
@@ -15,6 +19,16 @@ type TopicAgreement struct {
 	MeetingTopicID string `json:"meeting_topic_id" db:"meeting_topic_id"`
 	CreatedBy      string `json:"created_by" db:"created_by"`
 	CreatedAt      string `json:"created_at" db:"created_at"`
+	// Task és la tasca de seguiment de l'acord, si n'hi ha
+	Task *AgreementTask `json:"task,omitempty" db:"-"`
+}
+
+// AgreementTask és l'estat de la tasca d'un acord tal com es mostra a la reunió.
+type AgreementTask struct {
+	ID      uuid.UUID  `json:"id" db:"id"`
+	Status  string     `json:"status" db:"status"`
+	UserID  uuid.UUID  `json:"user_id" db:"user_id"`
+	EndDate *time.Time `json:"end_date,omitempty" db:"end_date"`
 }
 type Participant struct {
 	ID        string `json:"id" db:"id"`
@@ -32,6 +46,10 @@ type Meeting struct {
 	CreatedAt    string         `json:"created_at" db:"created_at"`
 	Participants *[]Participant `json:"participants" db:"participants"`
 	Topics       *[]Topic       `json:"topics" db:"topics"`
+	// SeriesID i OccurrenceStart només hi són si la reunió és una ocurrència d'una sèrie.
+	// OccurrenceStart és l'hora que li tocava segons la regla, encara que s'hagi mogut.
+	SeriesID        *uuid.UUID `json:"series_id,omitempty" db:"series_id"`
+	OccurrenceStart *time.Time `json:"occurrence_start,omitempty" db:"occurrence_start"`
 }
 
 type MeetingSummary struct {
@@ -43,4 +61,69 @@ type MeetingSummary struct {
 	NumTopics   int    `json:"num_topics" db:"num_topics"`
 	NumParticipants int `json:"num_participants" db:"num_participants"`
 	HasAgreements bool `json:"has_agreements" db:"has_agreements"`
-}
\ No newline at end of file
+	SeriesID      *uuid.UUID `json:"series_id,omitempty" db:"series_id"`
+}
+
+// MeetingSeries és una reunió periòdica. Les ocurrències es creen com a reunions normals
+// a mesura que es consulten, amb els participants i els temes de la plantilla.
+type MeetingSeries struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	GroupID        uuid.UUID      `json:"group_id" db:"group_id"`
+	Title          string         `json:"title" db:"title"`
+	Description    string         `json:"description" db:"description"`
+	StartTime      time.Time      `json:"start_time" db:"start_time"`
+	Rule           RecurrenceRule `json:"-" db:"-"`
+	RRule          string         `json:"rrule" db:"rrule"`
+	ParticipantIDs []uuid.UUID    `json:"participant_ids" db:"participant_ids"`
+	Topics         []string       `json:"topics" db:"topic_titles"`
+	CreatedBy      uuid.UUID      `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	// MaterializedUntil és fins on ja s'han creat les ocurrències (exclòs)
+	MaterializedUntil *time.Time `json:"-" db:"materialized_until"`
+}
+// Minutes són les dades de l'acta d'una reunió, amb els noms ja resolts, per a les plantilles.
+type Minutes struct {
+	MeetingID    uuid.UUID
+	Title        string
+	Description  string
+	GroupName    string
+	StartTime    time.Time
+	Participants []string
+	Topics       []MinutesTopic
+}
+
+type MinutesTopic struct {
+	Title      string
+	Agreements []MinutesAgreement
+}
+
+type MinutesAgreement struct {
+	Title  string
+	Author string
+}
+
+// ActionItem és una tasca oberta que es revisa a una reunió: la d'un acord de la reunió o
+// una que s'hi ha traspassat d'una reunió anterior.
+type ActionItem struct {
+	TaskID      uuid.UUID  `json:"task_id" db:"task_id"`
+	Description string     `json:"description" db:"description"`
+	Status      string     `json:"status" db:"status"`
+	Priority    string     `json:"priority" db:"priority"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	Assignee    string     `json:"assignee" db:"assignee"`
+	EndDate     *time.Time `json:"end_date,omitempty" db:"end_date"`
+	ProjectID   *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	// AgreementID, AgreementTitle i TopicTitle són buits si l'acord s'ha esborrat
+	AgreementID    *uuid.UUID `json:"agreement_id,omitempty" db:"agreement_id"`
+	AgreementTitle string     `json:"agreement_title" db:"agreement_title"`
+	TopicTitle     string     `json:"topic_title" db:"topic_title"`
+	// MeetingID és la reunió de l'acord i Carried indica que la tasca ve d'una reunió anterior
+	MeetingID *uuid.UUID `json:"meeting_id,omitempty" db:"meeting_id"`
+	Carried   bool       `json:"carried" db:"carried"`
+}
+
+// CarryOver és el resultat de traspassar les tasques obertes a la reunió següent del grup.
+type CarryOver struct {
+	MeetingID   uuid.UUID    `json:"meeting_id"`
+	ActionItems []ActionItem `json:"action_items"`
+}
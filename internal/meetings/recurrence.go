@@ -0,0 +1,295 @@
+package meetings
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Freqüències de l'RRULE que es poden fer servir a una sèrie.
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// maxRecurrencePeriods limita els períodes que es recorren per generar ocurrències, perquè
+// una regla que no encaixa mai (p. ex. el dia 31 cada 12 mesos començant al febrer) no
+// deixi el bucle obert.
+const maxRecurrencePeriods = 10000
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RecurrenceDay és un element de BYDAY. Ordinal només té sentit a MONTHLY: 1 és el
+// primer dia d'aquell tipus del mes, -1 l'últim i 0 tots.
+type RecurrenceDay struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// RecurrenceRule és el subconjunt de l'RRULE de l'RFC 5545 que admeten les sèries:
+// FREQ (DAILY, WEEKLY o MONTHLY), INTERVAL, BYDAY i UNTIL o COUNT.
+type RecurrenceRule struct {
+	Freq     string
+	Interval int
+	ByDay    []RecurrenceDay
+	Until    *time.Time
+	Count    int
+}
+
+// ParseRecurrenceRule interpreta una RRULE com "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10".
+// Accepta el prefix "RRULE:".
+func ParseRecurrenceRule(value string) (RecurrenceRule, error) {
+	rule := RecurrenceRule{Interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return RecurrenceRule{}, ErrInvalidRecurrence
+	}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || val == "" || seen[name] {
+			return RecurrenceRule{}, ErrInvalidRecurrence
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if val != FreqDaily && val != FreqWeekly && val != FreqMonthly {
+				return RecurrenceRule{}, ErrInvalidRecurrence
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return RecurrenceRule{}, ErrInvalidRecurrence
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, err := parseRecurrenceDay(code)
+				if err != nil {
+					return RecurrenceRule{}, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return RecurrenceRule{}, ErrInvalidRecurrence
+			}
+			rule.Until = &until
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return RecurrenceRule{}, ErrInvalidRecurrence
+			}
+			rule.Count = count
+		default:
+			return RecurrenceRule{}, ErrInvalidRecurrence
+		}
+	}
+	if rule.Freq == "" || (rule.Until != nil && rule.Count > 0) {
+		return RecurrenceRule{}, ErrInvalidRecurrence
+	}
+	for _, day := range rule.ByDay {
+		if day.Ordinal != 0 && rule.Freq != FreqMonthly {
+			return RecurrenceRule{}, ErrInvalidRecurrence
+		}
+	}
+	return rule, nil
+}
+
+func parseRecurrenceDay(code string) (RecurrenceDay, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < 2 {
+		return RecurrenceDay{}, ErrInvalidRecurrence
+	}
+	weekday, ok := weekdayCodes[code[len(code)-2:]]
+	if !ok {
+		return RecurrenceDay{}, ErrInvalidRecurrence
+	}
+	day := RecurrenceDay{Weekday: weekday}
+	if prefix := code[:len(code)-2]; prefix != "" {
+		ordinal, err := strconv.Atoi(prefix)
+		if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+			return RecurrenceDay{}, ErrInvalidRecurrence
+		}
+		day.Ordinal = ordinal
+	}
+	return day, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	// Una data sola inclou tot el dia
+	t, err := time.ParseInLocation("20060102", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// String torna la regla en format RRULE, sense el prefix.
+func (r RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (d RecurrenceDay) String() string {
+	for code, weekday := range weekdayCodes {
+		if weekday == d.Weekday {
+			if d.Ordinal != 0 {
+				return fmt.Sprintf("%d%s", d.Ordinal, code)
+			}
+			return code
+		}
+	}
+	return ""
+}
+
+// Occurrences retorna les ocurrències de la regla a [from, to) per a una sèrie que comença
+// a start. Les ocurrències es calculen en hora local per mantenir l'hora de la reunió
+// quan canvia l'horari d'estiu, i COUNT es compta sempre des de start.
+func (r RecurrenceRule) Occurrences(start, from, to time.Time) []time.Time {
+	start = start.In(time.Local)
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	var occurrences []time.Time
+	count := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, candidate := range r.periodCandidates(start, period*interval) {
+			if candidate.Before(start) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return occurrences
+			}
+			if !candidate.Before(to) {
+				return occurrences
+			}
+			count++
+			if !candidate.Before(from) {
+				occurrences = append(occurrences, candidate)
+			}
+			if r.Count > 0 && count >= r.Count {
+				return occurrences
+			}
+		}
+	}
+	return occurrences
+}
+
+// periodCandidates retorna, ordenades, les dates del període offset (en dies, setmanes o
+// mesos des de start) que compleixen BYDAY.
+func (r RecurrenceRule) periodCandidates(start time.Time, offset int) []time.Time {
+	hour, min, sec := start.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.Local)
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case FreqDaily:
+		day := at(start.Year(), start.Month(), start.Day()+offset)
+		if len(r.ByDay) == 0 || r.hasWeekday(day.Weekday()) {
+			candidates = append(candidates, day)
+		}
+	case FreqWeekly:
+		// Les setmanes comencen en dilluns (WKST=MO)
+		monday := start.Day() - (int(start.Weekday())+6)%7 + offset*7
+		if len(r.ByDay) == 0 {
+			candidates = append(candidates, at(start.Year(), start.Month(), start.Day()+offset*7))
+			break
+		}
+		for _, day := range r.ByDay {
+			candidates = append(candidates, at(start.Year(), start.Month(), monday+(int(day.Weekday)+6)%7))
+		}
+	case FreqMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.Local)
+		year, month := first.Year(), first.Month()
+		days := time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local).Day()
+		if len(r.ByDay) == 0 {
+			// Els mesos que no tenen el dia de start se salten
+			if start.Day() <= days {
+				candidates = append(candidates, at(year, month, start.Day()))
+			}
+			break
+		}
+		for _, day := range r.ByDay {
+			firstMatch := 1 + (int(day.Weekday)-int(first.Weekday())+7)%7
+			switch {
+			case day.Ordinal == 0:
+				for d := firstMatch; d <= days; d += 7 {
+					candidates = append(candidates, at(year, month, d))
+				}
+			case day.Ordinal > 0:
+				if d := firstMatch + (day.Ordinal-1)*7; d <= days {
+					candidates = append(candidates, at(year, month, d))
+				}
+			default:
+				lastMatch := firstMatch + (days-firstMatch)/7*7
+				if d := lastMatch + (day.Ordinal+1)*7; d >= 1 {
+					candidates = append(candidates, at(year, month, d))
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	unique := candidates[:0]
+	for i, candidate := range candidates {
+		if i == 0 || !candidate.Equal(candidates[i-1]) {
+			unique = append(unique, candidate)
+		}
+	}
+	return unique
+}
+
+func (r RecurrenceRule) hasWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// CountBefore compta les ocurrències anteriors a at. Serveix per repartir el COUNT quan
+// una sèrie es parteix.
+func (r RecurrenceRule) CountBefore(start, at time.Time) int {
+	return len(r.Occurrences(start, start, at))
+}
@@ -0,0 +1,116 @@
+package meetings
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	// Les ocurrències es calculen en hora local: fem servir una zona amb horari d'estiu
+	loc, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("Europe/Madrid not available: %v", err)
+	}
+	local := time.Local
+	time.Local = loc
+	defer func() { time.Local = local }()
+
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		from  string
+		to    string
+		want  []string
+	}{
+		{
+			name: "weekly on two days", rule: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=5",
+			start: "2026-03-02 10:00", from: "2026-03-01 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-03-02 10:00", "2026-03-05 10:00", "2026-03-09 10:00", "2026-03-12 10:00", "2026-03-16 10:00"},
+		},
+		{
+			name: "every other day", rule: "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			start: "2026-03-02 09:30", from: "2026-03-01 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-03-02 09:30", "2026-03-04 09:30", "2026-03-06 09:30"},
+		},
+		{
+			name: "keeps the local hour across daylight saving", rule: "FREQ=WEEKLY;COUNT=3",
+			start: "2026-03-23 10:00", from: "2026-03-01 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-03-23 10:00", "2026-03-30 10:00", "2026-04-06 10:00"},
+		},
+		{
+			name: "skips months without the day", rule: "FREQ=MONTHLY;COUNT=3",
+			start: "2026-01-31 12:00", from: "2026-01-01 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-01-31 12:00", "2026-03-31 12:00", "2026-05-31 12:00"},
+		},
+		{
+			name: "last friday of the month", rule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			start: "2026-01-30 16:00", from: "2026-01-01 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-01-30 16:00", "2026-02-27 16:00", "2026-03-27 16:00"},
+		},
+		{
+			name: "first monday of the month", rule: "FREQ=MONTHLY;BYDAY=1MO;COUNT=3",
+			start: "2026-03-02 10:00", from: "2026-03-01 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-03-02 10:00", "2026-04-06 10:00", "2026-05-04 10:00"},
+		},
+		{
+			name: "until a date includes the whole day", rule: "FREQ=DAILY;UNTIL=20260304",
+			start: "2026-03-02 18:00", from: "2026-03-01 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-03-02 18:00", "2026-03-03 18:00", "2026-03-04 18:00"},
+		},
+		{
+			name: "count starts at the series start", rule: "FREQ=WEEKLY;COUNT=4",
+			start: "2026-03-02 10:00", from: "2026-03-10 00:00", to: "2027-01-01 00:00",
+			want: []string{"2026-03-16 10:00", "2026-03-23 10:00"},
+		},
+		{
+			name: "window end is exclusive", rule: "FREQ=DAILY",
+			start: "2026-03-02 10:00", from: "2026-03-02 00:00", to: "2026-03-04 10:00",
+			want: []string{"2026-03-02 10:00", "2026-03-03 10:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q): %v", tt.rule, err)
+			}
+			got := []string{}
+			for _, occurrence := range rule.Occurrences(at(tt.start), at(tt.from), at(tt.to)) {
+				got = append(got, occurrence.In(loc).Format("2006-01-02 15:04"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRuleRejectsInvalidRules(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"FREQ=YEARLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=DAILY;COUNT=3;UNTIL=20260101",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYMONTH=1",
+	} {
+		if _, err := ParseRecurrenceRule(value); err != ErrInvalidRecurrence {
+			t.Errorf("ParseRecurrenceRule(%q) error = %v, want ErrInvalidRecurrence", value, err)
+		}
+	}
+}
@@ -15,7 +15,7 @@ type MeetingRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	FindByID(ctx context.Context, id uuid.UUID) (Meeting, error)
 	FindByGroupID(ctx context.Context, groupID uuid.UUID) ([]MeetingSummary, error)
-	FindBetweenDates(ctx context.Context, startDate, endDate time.Time, groupID uuid.UUID) ([]MeetingSummary, error)
+	FindBetweenDates(ctx context.Context, startDate, endDate time.Time, groupID, memberID uuid.UUID) ([]MeetingSummary, error)
 	AddParticipant(ctx context.Context, id uuid.UUID, request MeetingParticipantRequest) error
 	RemoveParticipant(ctx context.Context, id uuid.UUID) error
 	AddTopics(ctx context.Context, id uuid.UUID, request MeetingTopicsRequest) (Topic, error)
@@ -23,6 +23,23 @@ type MeetingRepository interface {
 	AddTopicAgreements(ctx context.Context, id uuid.UUID, request MeetingTopicAgreementRequest) (TopicAgreement, error)
 	UpdateTopicAgreements(ctx context.Context, id uuid.UUID, request MeetingTopicAgreementRequest) (TopicAgreement, error)
 	RemoveTopicAgreements(ctx context.Context, id uuid.UUID, ) error
+	FindGroupIDByMeetingID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	FindGroupIDByParticipantID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	FindGroupIDByTopicID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	FindGroupIDByAgreementID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	CreateSeries(ctx context.Context, series MeetingSeries) (MeetingSeries, error)
+	FindSeriesByID(ctx context.Context, id uuid.UUID) (MeetingSeries, error)
+	FindSeriesToMaterialize(ctx context.Context, groupID, memberID uuid.UUID, until time.Time) ([]MeetingSeries, error)
+	FindSeriesExceptions(ctx context.Context, seriesID uuid.UUID) ([]time.Time, error)
+	MaterializeSeries(ctx context.Context, series MeetingSeries, starts []time.Time, until time.Time) error
+	UpdateSeriesTemplate(ctx context.Context, series MeetingSeries) error
+	SplitSeries(ctx context.Context, old, next MeetingSeries, at time.Time, shift time.Duration, regenerate bool) (MeetingSeries, error)
+	TruncateSeries(ctx context.Context, series MeetingSeries, at time.Time) error
+	FindMinutes(ctx context.Context, id uuid.UUID) (Minutes, error)
+	FindAgreementTitle(ctx context.Context, id uuid.UUID) (string, error)
+	FindActionItems(ctx context.Context, meetingID uuid.UUID, doneStatus string) ([]ActionItem, error)
+	FindNextMeetingID(ctx context.Context, meetingID uuid.UUID) (uuid.UUID, error)
+	CarryOverActionItems(ctx context.Context, from, to uuid.UUID, doneStatus string) error
 }
 
 type meetingRepository struct {
@@ -58,29 +75,45 @@ func (r *meetingRepository) Update(ctx context.Context, request *Meeting) (Meeti
 	return *request, nil
 }
 
+// Delete esborra la reunió amb els seus temes, acords i participants. Si és una ocurrència
+// d'una sèrie, en deixa l'excepció perquè no es torni a crear.
 func (r *meetingRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx, `
-	DELETE FROM meetings
-	WHERE id = $1`,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO meeting_series_exceptions (series_id, occurrence_start)
+	SELECT series_id, occurrence_start
+	FROM meetings
+	WHERE id = $1 AND series_id IS NOT NULL
+	ON CONFLICT DO NOTHING`,
 		id,
 	)
 	if err != nil {
 		return fmt.Errorf("error deleting meeting: %w", err)
 	}
-	return nil
+	if err := deleteMeetingsTx(ctx, tx, `m.id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *meetingRepository) FindByID(ctx context.Context, id uuid.UUID) (Meeting, error) {
 	query := `
 		SELECT
-			m.id, m.group_id, m.title, m.description, m.start_time, m.created_by, m.created_at,
+			m.id, m.group_id, m.title, m.description, m.start_time, m.created_by, m.created_at, m.series_id, m.occurrence_start,
 			p.id, p.user_id,
 			t.id, t.title, t.created_at,
-			a.id, a.title, a.created_by, a.created_at
+			a.id, a.title, a.created_by, a.created_at,
+			tk.id, tk.status, tk.user_id, tk.end_date
 		FROM meetings m
 		LEFT JOIN meeting_participants p ON m.id = p.meeting_id
 		LEFT JOIN meeting_topics t ON m.id = t.meeting_id
 		LEFT JOIN meeting_topic_agreements a ON t.id = a.meeting_topic_id
+		LEFT JOIN tasks tk ON tk.agreement_id = a.id
 		WHERE m.id = $1
 	`
 
@@ -100,16 +133,22 @@ func (r *meetingRepository) FindByID(ctx context.Context, id uuid.UUID) (Meeting
 	for rows.Next() {
 		var (
 			meetingID, groupID, title, description, startTime, createdBy, createdAt string
+			seriesID                                                                uuid.NullUUID
+			occurrenceStart                                                         sql.NullTime
 			participantID, participantUserID                                        sql.NullString
 			topicID, topicTitle, topicCreatedAt                                    sql.NullString
 			agreementID, agreementTitle, agreementCreatedBy, agreementCreatedAt    sql.NullString
+			taskID, taskUserID                                                      uuid.NullUUID
+			taskStatus                                                              sql.NullString
+			taskEndDate                                                             sql.NullTime
 		)
 
 		err := rows.Scan(
-			&meetingID, &groupID, &title, &description, &startTime, &createdBy, &createdAt,
+			&meetingID, &groupID, &title, &description, &startTime, &createdBy, &createdAt, &seriesID, &occurrenceStart,
 			&participantID, &participantUserID,
 			&topicID, &topicTitle, &topicCreatedAt,
 			&agreementID, &agreementTitle, &agreementCreatedBy, &agreementCreatedAt,
+			&taskID, &taskStatus, &taskUserID, &taskEndDate,
 		)
 		if err != nil {
 			return Meeting{}, err
@@ -124,6 +163,10 @@ func (r *meetingRepository) FindByID(ctx context.Context, id uuid.UUID) (Meeting
 			meeting.StartTime = startTime
 			meeting.CreatedBy = createdBy
 			meeting.CreatedAt = createdAt
+			if seriesID.Valid {
+				meeting.SeriesID = &seriesID.UUID
+				meeting.OccurrenceStart = &occurrenceStart.Time
+			}
 		}
 
 		// Participants
@@ -157,13 +200,21 @@ func (r *meetingRepository) FindByID(ctx context.Context, id uuid.UUID) (Meeting
 
 		// Acords
 		if agreementID.Valid && currentTopic != nil {
-			*currentTopic.TopicAgreements = append(*currentTopic.TopicAgreements, TopicAgreement{
+			agreement := TopicAgreement{
 				ID:             agreementID.String,
 				Title:          agreementTitle.String,
 				CreatedBy:      agreementCreatedBy.String,
 				CreatedAt:      agreementCreatedAt.String,
 				MeetingTopicID: topicID.String,
-			})
+			}
+			// Tasca de seguiment de l'acord
+			if taskID.Valid {
+				agreement.Task = &AgreementTask{ID: taskID.UUID, Status: taskStatus.String, UserID: taskUserID.UUID}
+				if taskEndDate.Valid {
+					agreement.Task.EndDate = &taskEndDate.Time
+				}
+			}
+			*currentTopic.TopicAgreements = append(*currentTopic.TopicAgreements, agreement)
 		}
 	}
 
@@ -182,18 +233,24 @@ func (r *meetingRepository) FindByID(ctx context.Context, id uuid.UUID) (Meeting
 func (r *meetingRepository) FindByGroupID(ctx context.Context, id uuid.UUID) ([]MeetingSummary, error) {
 	query := `
 		SELECT
-			m.id, m.group_id, m.title, m.description, m.start_time, m.created_by, m.created_at,
+			m.id, 
+			g.name as group_name, 
+			m.title, 
+			m.start_time, 
+			m.created_by, 
 			COUNT(DISTINCT p.id) AS num_participants,
 			COUNT(DISTINCT t.id) AS num_topics,
-			COUNT(DISTINCT a.id) > 0 AS has_agreements
+			COUNT(DISTINCT a.id) > 0 AS has_agreements,
+			m.series_id
 		FROM meetings m		
+			INNER JOIN groups g ON m.group_id = g.id
 		LEFT JOIN meeting_participants p ON m.id = p.meeting_id
 		LEFT JOIN meeting_topics t ON m.id = t.meeting_id
 		LEFT JOIN meeting_topic_agreements a ON t.id = a.meeting_topic_id
 		WHERE m.group_id = $1
-		GROUP BY m.id, m.group_id, m.title, m.description, m.start_time, m.created_by, m.created_at
+		GROUP BY m.id, g.name, m.title, m.start_time, m.created_by, m.series_id
 	`
-	rows, err := r.db.Query(query, id)
+	rows, err := r.db.QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -203,8 +260,8 @@ func (r *meetingRepository) FindByGroupID(ctx context.Context, id uuid.UUID) ([]
 		var meeting MeetingSummary
 		if err := rows.Scan(
 			&meeting.ID, &meeting.GroupName, &meeting.Title, &meeting.StartTime,
-			&meeting.CreatedBy, &meeting.NumTopics, &meeting.NumParticipants,
-			&meeting.HasAgreements,
+			&meeting.CreatedBy, &meeting.NumParticipants, &meeting.NumTopics,
+			&meeting.HasAgreements, &meeting.SeriesID,
 		); err != nil {
 			return nil, err
 		}
@@ -216,7 +273,9 @@ func (r *meetingRepository) FindByGroupID(ctx context.Context, id uuid.UUID) ([]
 	return meetings, nil
 }
 
-func (r *meetingRepository) FindBetweenDates(ctx context.Context, startDate, endDate time.Time, groupId uuid.UUID) ([]MeetingSummary, error) {
+// FindBetweenDates filtra pel grup si groupId no és uuid.Nil, i només pels grups de
+// memberID si no és uuid.Nil.
+func (r *meetingRepository) FindBetweenDates(ctx context.Context, startDate, endDate time.Time, groupId, memberID uuid.UUID) ([]MeetingSummary, error) {
 	query := `
 		SELECT
 			m.id, 
@@ -226,16 +285,20 @@ func (r *meetingRepository) FindBetweenDates(ctx context.Context, startDate, end
 			m.created_by, 			
 			COUNT(DISTINCT p.id) AS num_participants,
 			COUNT(DISTINCT t.id) AS num_topics,
-			COUNT(DISTINCT a.id) > 0 AS has_agreements
+			COUNT(DISTINCT a.id) > 0 AS has_agreements,
+			m.series_id
 		FROM meetings m		
 			INNER JOIN groups g ON m.group_id = g.id
 		LEFT JOIN meeting_participants p ON m.id = p.meeting_id
 		LEFT JOIN meeting_topics t ON m.id = t.meeting_id
 		LEFT JOIN meeting_topic_agreements a ON t.id = a.meeting_topic_id
 		WHERE m.start_time BETWEEN $1 AND $2 
-		GROUP BY m.id, g.name, m.title, m.start_time, m.created_by
+			AND ($3 = '00000000-0000-0000-0000-000000000000'::uuid OR m.group_id = $3)
+			AND ($4 = '00000000-0000-0000-0000-000000000000'::uuid OR EXISTS (
+				SELECT 1 FROM group_members gm WHERE gm.group_id = m.group_id AND gm.user_id = $4))
+		GROUP BY m.id, g.name, m.title, m.start_time, m.created_by, m.series_id
 	`
-	rows, err := r.db.Query(query, startDate, endDate)
+	rows, err := r.db.QueryContext(ctx, query, startDate, endDate, groupId, memberID)
 	if err != nil {
 		return nil, err
 	}
@@ -246,7 +309,7 @@ func (r *meetingRepository) FindBetweenDates(ctx context.Context, startDate, end
 		if err := rows.Scan(
 			&meeting.ID, &meeting.GroupName, &meeting.Title, &meeting.StartTime,
 			&meeting.CreatedBy, &meeting.NumParticipants, &meeting.NumTopics,
-			&meeting.HasAgreements,
+			&meeting.HasAgreements, &meeting.SeriesID,
 		); err != nil {
 			return nil, err
 		}
@@ -321,9 +384,9 @@ func (r *meetingRepository) AddTopicAgreements(ctx context.Context, id uuid.UUID
 func (r *meetingRepository) UpdateTopicAgreements(ctx context.Context, id uuid.UUID, request MeetingTopicAgreementRequest) (TopicAgreement, error) {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE meeting_topic_agreements
-		SET title = $1, created_by = $2
-		WHERE id = $3`,
-		request.Title, request.CreatedBy, id,
+		SET title = $1
+		WHERE id = $2`,
+		request.Title, id,
 	)
 	if err != nil {
 		return TopicAgreement{}, fmt.Errorf("error updating topic agreement: %w", err)
@@ -340,4 +403,119 @@ func (r *meetingRepository) RemoveTopicAgreements(ctx context.Context, id uuid.U
 		return fmt.Errorf("error removing topic agreement: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// Les consultes FindGroupIDBy* retornen el grup d'on penja cada element, per comprovar-hi
+// el rol de l'usuari. Retornen ErrMeetingNotFound si l'element no existeix.
+func (r *meetingRepository) FindGroupIDByMeetingID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return r.findGroupID(ctx, `
+		SELECT group_id
+		FROM meetings
+		WHERE id = $1`, id)
+}
+
+func (r *meetingRepository) FindGroupIDByParticipantID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return r.findGroupID(ctx, `
+		SELECT m.group_id
+		FROM meeting_participants p
+			INNER JOIN meetings m ON m.id = p.meeting_id
+		WHERE p.id = $1`, id)
+}
+
+func (r *meetingRepository) FindGroupIDByTopicID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return r.findGroupID(ctx, `
+		SELECT m.group_id
+		FROM meeting_topics t
+			INNER JOIN meetings m ON m.id = t.meeting_id
+		WHERE t.id = $1`, id)
+}
+
+func (r *meetingRepository) FindGroupIDByAgreementID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return r.findGroupID(ctx, `
+		SELECT m.group_id
+		FROM meeting_topic_agreements a
+			INNER JOIN meeting_topics t ON t.id = a.meeting_topic_id
+			INNER JOIN meetings m ON m.id = t.meeting_id
+		WHERE a.id = $1`, id)
+}
+
+func (r *meetingRepository) findGroupID(ctx context.Context, query string, id uuid.UUID) (uuid.UUID, error) {
+	var groupID uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrMeetingNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return groupID, nil
+}
+
+// FindMinutes retorna l'acta de la reunió amb els temes i acords per ordre de creació.
+func (r *meetingRepository) FindMinutes(ctx context.Context, id uuid.UUID) (Minutes, error) {
+	minutes := Minutes{MeetingID: id, Participants: []string{}, Topics: []MinutesTopic{}}
+	var startTime sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(m.title, ''), COALESCE(m.description, ''), g.name, m.start_time
+		FROM meetings m
+			INNER JOIN groups g ON m.group_id = g.id
+		WHERE m.id = $1`, id).Scan(&minutes.Title, &minutes.Description, &minutes.GroupName, &startTime)
+	if err == sql.ErrNoRows {
+		return Minutes{}, ErrMeetingNotFound
+	}
+	if err != nil {
+		return Minutes{}, err
+	}
+	minutes.StartTime = startTime.Time
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT TRIM(u.name || ' ' || u.surname)
+		FROM meeting_participants p
+			INNER JOIN users u ON u.id = p.user_id
+		WHERE p.meeting_id = $1
+		ORDER BY u.surname, u.name`, id)
+	if err != nil {
+		return Minutes{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return Minutes{}, err
+		}
+		minutes.Participants = append(minutes.Participants, name)
+	}
+	if err := rows.Err(); err != nil {
+		return Minutes{}, err
+	}
+
+	topicRows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, COALESCE(t.title, ''), a.id, COALESCE(a.title, ''), COALESCE(TRIM(u.name || ' ' || u.surname), '')
+		FROM meeting_topics t
+			LEFT JOIN meeting_topic_agreements a ON a.meeting_topic_id = t.id
+			LEFT JOIN users u ON u.id = a.created_by
+		WHERE t.meeting_id = $1
+		ORDER BY t.created_at, t.id, a.created_at, a.id`, id)
+	if err != nil {
+		return Minutes{}, err
+	}
+	defer topicRows.Close()
+	var currentTopic uuid.UUID
+	for topicRows.Next() {
+		var topicID uuid.UUID
+		var agreementID uuid.NullUUID
+		var topicTitle, agreementTitle, author string
+		if err := topicRows.Scan(&topicID, &topicTitle, &agreementID, &agreementTitle, &author); err != nil {
+			return Minutes{}, err
+		}
+		if topicID != currentTopic || len(minutes.Topics) == 0 {
+			minutes.Topics = append(minutes.Topics, MinutesTopic{Title: topicTitle, Agreements: []MinutesAgreement{}})
+			currentTopic = topicID
+		}
+		if agreementID.Valid {
+			topic := &minutes.Topics[len(minutes.Topics)-1]
+			topic.Agreements = append(topic.Agreements, MinutesAgreement{Title: agreementTitle, Author: author})
+		}
+	}
+	return minutes, topicRows.Err()
+}
@@ -1,22 +1,34 @@
 package meetings
 
-import "github.com/gin-gonic/gin"
+import (
+	"orkestra-api/middleware"
 
-func RegisterRoutes(router *gin.RouterGroup, handler *MeetingHandler) {
-	router.POST("/meetings", handler.CreateMeeting)
-	router.PUT("/meetings/:id", handler.UpdateMeeting)
-	router.DELETE("/meetings/:id", handler.DeleteMeeting)
-	router.GET("/meetings/:id", handler.GetMeetingByID)
-	router.GET("/meetings/group/:id", handler.GetMeetingsByGroupID)
-	router.GET("/meetings/dates", handler.GetMeetingsBetweenDates)
+	"github.com/gin-gonic/gin"
+)
 
-	router.POST("/meetings/participants", handler.AddParticipant)
-	router.DELETE("/meetings/participants/:id", handler.RemoveParticipant)
+func RegisterRoutes(router *gin.RouterGroup, handler *MeetingHandler, authz *middleware.Authorizer) {
+	router.POST("/meetings", authz.Require("meetings:write"), handler.CreateMeeting)
+	router.PUT("/meetings/:id", authz.Require("meetings:write"), handler.UpdateMeeting)
+	router.DELETE("/meetings/:id", authz.Require("meetings:write"), handler.DeleteMeeting)
+	router.GET("/meetings/:id", authz.Require("meetings:read"), handler.GetMeetingByID)
+	router.GET("/meetings/group/:id", authz.Require("meetings:read"), handler.GetMeetingsByGroupID)
+	router.GET("/meetings/dates", authz.Require("meetings:read"), handler.GetMeetingsBetweenDates)
+	router.GET("/meetings/:id/minutes", authz.Require("meetings:read"), handler.GetMeetingMinutes)
+	router.GET("/meetings/:id/action-items", authz.Require("meetings:read"), handler.GetActionItems)
+	router.POST("/meetings/:id/action-items/carry-over", authz.Require("meetings:write"), handler.CarryOverActionItems)
 
-	router.POST("/meetings/topics", handler.AddTopics)
-	router.DELETE("/meetings/topics/:id", handler.RemoveTopics)
+	router.POST("/meetings/series", authz.Require("meetings:write"), handler.CreateMeetingSeries)
+	router.GET("/meetings/series/:id", authz.Require("meetings:read"), handler.GetMeetingSeries)
+	router.PUT("/meetings/series/:id/template", authz.Require("meetings:write"), handler.UpdateMeetingSeriesTemplate)
 
-	router.POST("/meetings/topic-agreements", handler.AddTopicAgreements)
-	router.PUT("/meetings/topic-agreements/:id", handler.UpdateTopicAgreements)
-	router.DELETE("/meetings/topic-agreements/:id", handler.RemoveTopicAgreements)
+	router.POST("/meetings/participants", authz.Require("meetings:write"), handler.AddParticipant)
+	router.DELETE("/meetings/participants/:id", authz.Require("meetings:write"), handler.RemoveParticipant)
+
+	router.POST("/meetings/topics", authz.Require("meetings:write"), handler.AddTopics)
+	router.DELETE("/meetings/topics/:id", authz.Require("meetings:write"), handler.RemoveTopics)
+
+	router.POST("/meetings/topic-agreements", authz.Require("meetings:write"), handler.AddTopicAgreements)
+	router.PUT("/meetings/topic-agreements/:id", authz.Require("meetings:write"), handler.UpdateTopicAgreements)
+	router.DELETE("/meetings/topic-agreements/:id", authz.Require("meetings:write"), handler.RemoveTopicAgreements)
+	router.POST("/meetings/topic-agreements/:id/task", authz.Require("meetings:write"), handler.CreateAgreementTask)
 }
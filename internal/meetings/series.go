@@ -0,0 +1,264 @@
+package meetings
+
+import (
+	"context"
+	"orkestra-api/internal/groups"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// seriesHorizon és fins on es creen les ocurrències quan es crea una sèrie o es consulten
+// reunions: les lectures no creen mai res més enllà, encara que demanin dates posteriors.
+// maxSeriesHorizon és el límit per a les escriptures que necessiten una ocurrència concreta,
+// com el traspàs de tasques a la reunió següent.
+const (
+	seriesHorizon    = 90 * 24 * time.Hour
+	maxSeriesHorizon = 2 * 365 * 24 * time.Hour
+)
+
+// CreateSeries crea la sèrie en nom del caller, que ha de ser membre del grup, i les
+// ocurrències dels propers dies.
+func (s *meetingService) CreateSeries(ctx context.Context, caller groups.Caller, request CreateMeetingSeriesRequest) (MeetingSeries, error) {
+	groupID, err := uuid.Parse(request.GroupID)
+	if err != nil {
+		return MeetingSeries{}, ErrInvalidID
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, false); err != nil {
+		return MeetingSeries{}, err
+	}
+	startTime, err := time.Parse(time.RFC3339, request.StartTime)
+	if err != nil {
+		return MeetingSeries{}, ErrInvalidRequest
+	}
+	rule, err := ParseRecurrenceRule(request.RRule)
+	if err != nil {
+		return MeetingSeries{}, err
+	}
+	series := MeetingSeries{
+		ID:          uuid.New(),
+		GroupID:     groupID,
+		Title:       request.Title,
+		Description: request.Description,
+		StartTime:   startTime,
+		Rule:        rule,
+		RRule:       rule.String(),
+		CreatedBy:   caller.UserID,
+	}
+	if err := s.applyTemplate(ctx, &series, request.ParticipantIDs, request.Topics); err != nil {
+		return MeetingSeries{}, err
+	}
+
+	series, err = s.meetingRepository.CreateSeries(ctx, series)
+	if err != nil {
+		return MeetingSeries{}, err
+	}
+	if err := s.materializeSeries(ctx, series, time.Now().Add(seriesHorizon)); err != nil {
+		return MeetingSeries{}, err
+	}
+	return series, nil
+}
+
+func (s *meetingService) FindSeriesByID(ctx context.Context, caller groups.Caller, id string) (MeetingSeries, error) {
+	seriesID, err := uuid.Parse(id)
+	if err != nil {
+		return MeetingSeries{}, ErrInvalidID
+	}
+	series, err := s.meetingRepository.FindSeriesByID(ctx, seriesID)
+	if err != nil {
+		return MeetingSeries{}, err
+	}
+	if err := s.groupService.Authorize(ctx, caller, series.GroupID, false); err != nil {
+		return MeetingSeries{}, err
+	}
+	return series, nil
+}
+
+// UpdateSeriesTemplate canvia els participants i els temes de les ocurrències que encara no
+// s'han creat. Les reunions que ja existeixen no es toquen.
+func (s *meetingService) UpdateSeriesTemplate(ctx context.Context, caller groups.Caller, id string, request SeriesTemplateRequest) (MeetingSeries, error) {
+	seriesID, err := uuid.Parse(id)
+	if err != nil {
+		return MeetingSeries{}, ErrInvalidID
+	}
+	series, err := s.meetingRepository.FindSeriesByID(ctx, seriesID)
+	if err != nil {
+		return MeetingSeries{}, err
+	}
+	if err := s.groupService.Authorize(ctx, caller, series.GroupID, true); err != nil {
+		return MeetingSeries{}, err
+	}
+	if err := s.applyTemplate(ctx, &series, request.ParticipantIDs, request.Topics); err != nil {
+		return MeetingSeries{}, err
+	}
+	if err := s.meetingRepository.UpdateSeriesTemplate(ctx, series); err != nil {
+		return MeetingSeries{}, err
+	}
+	return series, nil
+}
+
+// applyTemplate valida els participants, que han de ser membres del grup, i treu els temes buits.
+func (s *meetingService) applyTemplate(ctx context.Context, series *MeetingSeries, participantIDs, topics []string) error {
+	series.ParticipantIDs = []uuid.UUID{}
+	seen := make(map[uuid.UUID]struct{}, len(participantIDs))
+	for _, id := range participantIDs {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return ErrInvalidID
+		}
+		if _, ok := seen[userID]; ok {
+			continue
+		}
+		seen[userID] = struct{}{}
+		membership, err := s.groupService.Membership(ctx, series.GroupID, userID)
+		if err != nil {
+			return err
+		}
+		if !membership.Member {
+			return ErrParticipantNotInGroup
+		}
+		series.ParticipantIDs = append(series.ParticipantIDs, userID)
+	}
+
+	series.Topics = []string{}
+	for _, topic := range topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			series.Topics = append(series.Topics, topic)
+		}
+	}
+	return nil
+}
+
+// updateFollowing aplica l'edició a l'ocurrència i a les següents partint la sèrie en dues.
+// Sense rrule la sèrie nova manté la regla i les reunions es mouen el mateix temps que
+// aquesta, amb tot el que tinguin. Amb rrule les reunions següents es tornen a crear.
+func (s *meetingService) updateFollowing(ctx context.Context, meeting Meeting, request UpdateMeetingRequest) (Meeting, error) {
+	series, err := s.meetingRepository.FindSeriesByID(ctx, *meeting.SeriesID)
+	if err != nil {
+		return Meeting{}, err
+	}
+	newStart, err := time.Parse(time.RFC3339, request.StartTime)
+	if err != nil {
+		return Meeting{}, ErrInvalidRequest
+	}
+	currentStart, err := time.Parse(time.RFC3339Nano, meeting.StartTime)
+	if err != nil {
+		return Meeting{}, err
+	}
+	at := *meeting.OccurrenceStart
+	shift := newStart.Sub(currentStart)
+
+	next := MeetingSeries{
+		ID:             uuid.New(),
+		GroupID:        series.GroupID,
+		Title:          request.Title,
+		Description:    request.Description,
+		ParticipantIDs: series.ParticipantIDs,
+		Topics:         series.Topics,
+		CreatedBy:      series.CreatedBy,
+	}
+	regenerate := request.RRule != ""
+	if regenerate {
+		next.Rule, err = ParseRecurrenceRule(request.RRule)
+		if err != nil {
+			return Meeting{}, err
+		}
+		next.StartTime = newStart
+	} else {
+		next.Rule = series.Rule
+		if next.Rule.Count > 0 {
+			next.Rule.Count -= next.Rule.CountBefore(series.StartTime, at)
+		}
+		next.StartTime = at.Add(shift)
+		if len(next.Rule.Occurrences(next.StartTime, next.StartTime, next.StartTime.Add(time.Second))) == 0 {
+			return Meeting{}, ErrRuleMismatch
+		}
+		if series.MaterializedUntil != nil {
+			until := series.MaterializedUntil.Add(shift)
+			next.MaterializedUntil = &until
+		}
+	}
+	next.RRule = next.Rule.String()
+
+	series.Rule = truncatedRule(series.Rule, at)
+	series.RRule = series.Rule.String()
+	next, err = s.meetingRepository.SplitSeries(ctx, series, next, at, shift, regenerate)
+	if err != nil {
+		return Meeting{}, err
+	}
+	if regenerate {
+		if err := s.materializeSeries(ctx, next, time.Now().Add(seriesHorizon)); err != nil {
+			return Meeting{}, err
+		}
+	}
+	return s.meetingRepository.FindByID(ctx, meeting.ID)
+}
+
+// deleteFollowing acaba la sèrie abans de l'ocurrència i n'esborra les reunions a partir d'aquesta.
+func (s *meetingService) deleteFollowing(ctx context.Context, meeting Meeting) error {
+	series, err := s.meetingRepository.FindSeriesByID(ctx, *meeting.SeriesID)
+	if err != nil {
+		return err
+	}
+	at := *meeting.OccurrenceStart
+	series.Rule = truncatedRule(series.Rule, at)
+	series.RRule = series.Rule.String()
+	return s.meetingRepository.TruncateSeries(ctx, series, at)
+}
+
+// truncatedRule retorna la regla acabada just abans de at. UNTIL substitueix COUNT perquè
+// l'RRULE no admet totes dues.
+func truncatedRule(rule RecurrenceRule, at time.Time) RecurrenceRule {
+	until := at.Add(-time.Second)
+	if rule.Until != nil && rule.Until.Before(until) {
+		until = *rule.Until
+	}
+	rule.Until = &until
+	rule.Count = 0
+	return rule
+}
+
+// materialize crea les ocurrències pendents fins a until de les sèries que compleixen els
+// filtres de FindSeriesToMaterialize.
+func (s *meetingService) materialize(ctx context.Context, groupID, memberID uuid.UUID, until time.Time) error {
+	if limit := time.Now().Add(maxSeriesHorizon); until.After(limit) {
+		until = limit
+	}
+	series, err := s.meetingRepository.FindSeriesToMaterialize(ctx, groupID, memberID, until)
+	if err != nil {
+		return err
+	}
+	for _, item := range series {
+		if err := s.materializeSeries(ctx, item, until); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *meetingService) materializeSeries(ctx context.Context, series MeetingSeries, until time.Time) error {
+	from := series.StartTime
+	if series.MaterializedUntil != nil {
+		if !series.MaterializedUntil.Before(until) {
+			return nil
+		}
+		from = *series.MaterializedUntil
+	}
+	exceptions, err := s.meetingRepository.FindSeriesExceptions(ctx, series.ID)
+	if err != nil {
+		return err
+	}
+	skip := make(map[int64]struct{}, len(exceptions))
+	for _, exception := range exceptions {
+		skip[exception.Unix()] = struct{}{}
+	}
+
+	var starts []time.Time
+	for _, start := range series.Rule.Occurrences(series.StartTime, from, until) {
+		if _, ok := skip[start.Unix()]; !ok {
+			starts = append(starts, start)
+		}
+	}
+	return s.meetingRepository.MaterializeSeries(ctx, series, starts, until)
+}
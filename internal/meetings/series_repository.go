@@ -0,0 +1,315 @@
+package meetings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func (r *meetingRepository) CreateSeries(ctx context.Context, series MeetingSeries) (MeetingSeries, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO meeting_series (id, group_id, title, description, start_time, rrule, participant_ids, topic_titles, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		RETURNING created_at`,
+		series.ID, series.GroupID, series.Title, series.Description, series.StartTime, series.RRule,
+		pq.Array(uuidStrings(series.ParticipantIDs)), pq.Array(series.Topics), series.CreatedBy,
+	).Scan(&series.CreatedAt)
+	if err != nil {
+		return MeetingSeries{}, fmt.Errorf("error inserting meeting series: %w", err)
+	}
+	return series, nil
+}
+
+func (r *meetingRepository) FindSeriesByID(ctx context.Context, id uuid.UUID) (MeetingSeries, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, group_id, title, COALESCE(description, ''), start_time, rrule, participant_ids, topic_titles, created_by, created_at, materialized_until
+		FROM meeting_series
+		WHERE id = $1`, id)
+	series, err := scanSeries(row)
+	if err == sql.ErrNoRows {
+		return MeetingSeries{}, ErrSeriesNotFound
+	}
+	return series, err
+}
+
+// FindSeriesToMaterialize retorna les sèries que no tenen ocurrències creades fins a until.
+// Filtra pel grup si groupID no és uuid.Nil, i només pels grups de memberID si no és uuid.Nil.
+func (r *meetingRepository) FindSeriesToMaterialize(ctx context.Context, groupID, memberID uuid.UUID, until time.Time) ([]MeetingSeries, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.id, s.group_id, s.title, COALESCE(s.description, ''), s.start_time, s.rrule, s.participant_ids, s.topic_titles, s.created_by, s.created_at, s.materialized_until
+		FROM meeting_series s
+		WHERE s.start_time < $1
+			AND (s.materialized_until IS NULL OR s.materialized_until < $1)
+			AND ($2 = '00000000-0000-0000-0000-000000000000'::uuid OR s.group_id = $2)
+			AND ($3 = '00000000-0000-0000-0000-000000000000'::uuid OR EXISTS (
+				SELECT 1 FROM group_members gm WHERE gm.group_id = s.group_id AND gm.user_id = $3))`,
+		until, groupID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []MeetingSeries
+	for rows.Next() {
+		item, err := scanSeries(rows)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, item)
+	}
+	return series, rows.Err()
+}
+
+func (r *meetingRepository) FindSeriesExceptions(ctx context.Context, seriesID uuid.UUID) ([]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT occurrence_start
+		FROM meeting_series_exceptions
+		WHERE series_id = $1`, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exceptions []time.Time
+	for rows.Next() {
+		var occurrence time.Time
+		if err := rows.Scan(&occurrence); err != nil {
+			return nil, err
+		}
+		exceptions = append(exceptions, occurrence)
+	}
+	return exceptions, rows.Err()
+}
+
+// MaterializeSeries crea les reunions de starts amb la plantilla de la sèrie i apunta que
+// la sèrie està creada fins a until. La fila de la sèrie queda bloquejada fins al final de la
+// transacció, així que les peticions concurrents esperen i, si una altra ja ho ha fet, no fa res.
+func (r *meetingRepository) MaterializeSeries(ctx context.Context, series MeetingSeries, starts []time.Time, until time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current sql.NullTime
+	if err := tx.QueryRowContext(ctx, `
+		SELECT materialized_until
+		FROM meeting_series
+		WHERE id = $1
+		FOR UPDATE`, series.ID).Scan(&current); err != nil {
+		return err
+	}
+	if current.Valid && !current.Time.Before(until) {
+		return nil
+	}
+
+	for _, start := range starts {
+		if current.Valid && start.Before(current.Time) {
+			continue
+		}
+		meetingID := uuid.New()
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO meetings (id, group_id, created_at, start_time, created_by, title, description, series_id, occurrence_start)
+			VALUES ($1, $2, NOW(), $3, $4, $5, $6, $7, $3)
+			ON CONFLICT (series_id, occurrence_start) WHERE series_id IS NOT NULL DO NOTHING`,
+			meetingID, series.GroupID, start, series.CreatedBy, series.Title, series.Description, series.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("error inserting meeting occurrence: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			continue
+		}
+
+		// Els participants que ja no són del grup no s'hi afegeixen
+		for _, userID := range series.ParticipantIDs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO meeting_participants (id, meeting_id, user_id)
+				SELECT $1, $2, $3
+				WHERE EXISTS (SELECT 1 FROM group_members WHERE group_id = $4 AND user_id = $3)`,
+				uuid.New(), meetingID, userID, series.GroupID,
+			); err != nil {
+				return fmt.Errorf("error adding participant: %w", err)
+			}
+		}
+		for _, title := range series.Topics {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO meeting_topics (id, meeting_id, title)
+				VALUES ($1, $2, $3)`,
+				uuid.New(), meetingID, title,
+			); err != nil {
+				return fmt.Errorf("error adding topic: %w", err)
+			}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE meeting_series
+		SET materialized_until = $1
+		WHERE id = $2`, until, series.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *meetingRepository) UpdateSeriesTemplate(ctx context.Context, series MeetingSeries) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE meeting_series
+		SET participant_ids = $1, topic_titles = $2
+		WHERE id = $3`,
+		pq.Array(uuidStrings(series.ParticipantIDs)), pq.Array(series.Topics), series.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating meeting series: %w", err)
+	}
+	return nil
+}
+
+// SplitSeries parteix la sèrie a l'ocurrència at: old ja porta la regla retallada i next és
+// la sèrie nova que comença en aquella ocurrència. Amb regenerate les reunions a partir de
+// at s'esborren i es tornen a crear amb la regla nova, i si no es mouen shift a la sèrie
+// nova conservant-ne els temes, participants i acords.
+func (r *meetingRepository) SplitSeries(ctx context.Context, old, next MeetingSeries, at time.Time, shift time.Duration, regenerate bool) (MeetingSeries, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return MeetingSeries{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE meeting_series
+		SET rrule = $1
+		WHERE id = $2`, old.RRule, old.ID); err != nil {
+		return MeetingSeries{}, fmt.Errorf("error updating meeting series: %w", err)
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO meeting_series (id, group_id, title, description, start_time, rrule, participant_ids, topic_titles, created_by, created_at, materialized_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), $10)
+		RETURNING created_at`,
+		next.ID, next.GroupID, next.Title, next.Description, next.StartTime, next.RRule,
+		pq.Array(uuidStrings(next.ParticipantIDs)), pq.Array(next.Topics), next.CreatedBy, next.MaterializedUntil,
+	).Scan(&next.CreatedAt)
+	if err != nil {
+		return MeetingSeries{}, fmt.Errorf("error inserting meeting series: %w", err)
+	}
+
+	if regenerate {
+		if err := deleteMeetingsTx(ctx, tx, `m.series_id = $1 AND m.occurrence_start >= $2`, old.ID, at); err != nil {
+			return MeetingSeries{}, err
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE meetings
+			SET series_id = $1,
+				title = $2,
+				description = $3,
+				start_time = start_time + make_interval(secs => $4),
+				occurrence_start = occurrence_start + make_interval(secs => $4)
+			WHERE series_id = $5 AND occurrence_start >= $6`,
+			next.ID, next.Title, next.Description, shift.Seconds(), old.ID, at,
+		); err != nil {
+			return MeetingSeries{}, fmt.Errorf("error moving meeting occurrences: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO meeting_series_exceptions (series_id, occurrence_start)
+			SELECT $1, occurrence_start + make_interval(secs => $2)
+			FROM meeting_series_exceptions
+			WHERE series_id = $3 AND occurrence_start >= $4`,
+			next.ID, shift.Seconds(), old.ID, at,
+		); err != nil {
+			return MeetingSeries{}, fmt.Errorf("error moving meeting series exceptions: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM meeting_series_exceptions
+		WHERE series_id = $1 AND occurrence_start >= $2`, old.ID, at); err != nil {
+		return MeetingSeries{}, err
+	}
+
+	return next, tx.Commit()
+}
+
+// TruncateSeries desa la regla retallada de la sèrie i n'esborra les reunions a partir de at.
+func (r *meetingRepository) TruncateSeries(ctx context.Context, series MeetingSeries, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE meeting_series
+		SET rrule = $1
+		WHERE id = $2`, series.RRule, series.ID); err != nil {
+		return fmt.Errorf("error updating meeting series: %w", err)
+	}
+	if err := deleteMeetingsTx(ctx, tx, `m.series_id = $1 AND m.occurrence_start >= $2`, series.ID, at); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteMeetingsTx esborra les reunions m que compleixen where amb els acords, temes i participants.
+func deleteMeetingsTx(ctx context.Context, tx *sql.Tx, where string, args ...any) error {
+	statements := []string{
+		`DELETE FROM meeting_topic_agreements WHERE meeting_topic_id IN (
+			SELECT t.id FROM meeting_topics t INNER JOIN meetings m ON m.id = t.meeting_id WHERE ` + where + `)`,
+		`DELETE FROM meeting_topics WHERE meeting_id IN (SELECT m.id FROM meetings m WHERE ` + where + `)`,
+		`DELETE FROM meeting_participants WHERE meeting_id IN (SELECT m.id FROM meetings m WHERE ` + where + `)`,
+		`DELETE FROM meetings m WHERE ` + where,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, args...); err != nil {
+			return fmt.Errorf("error deleting meetings: %w", err)
+		}
+	}
+	return nil
+}
+
+type seriesScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSeries(row seriesScanner) (MeetingSeries, error) {
+	var series MeetingSeries
+	var participantIDs []string
+	var createdBy uuid.NullUUID
+	var materializedUntil sql.NullTime
+	err := row.Scan(&series.ID, &series.GroupID, &series.Title, &series.Description, &series.StartTime, &series.RRule,
+		pq.Array(&participantIDs), pq.Array(&series.Topics), &createdBy, &series.CreatedAt, &materializedUntil)
+	if err != nil {
+		return MeetingSeries{}, err
+	}
+	series.Rule, err = ParseRecurrenceRule(series.RRule)
+	if err != nil {
+		return MeetingSeries{}, fmt.Errorf("meeting series %s: %w", series.ID, err)
+	}
+	series.ParticipantIDs = make([]uuid.UUID, 0, len(participantIDs))
+	for _, id := range participantIDs {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return MeetingSeries{}, err
+		}
+		series.ParticipantIDs = append(series.ParticipantIDs, userID)
+	}
+	if series.Topics == nil {
+		series.Topics = []string{}
+	}
+	series.CreatedBy = createdBy.UUID
+	if materializedUntil.Valid {
+		series.MaterializedUntil = &materializedUntil.Time
+	}
+	return series, nil
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
@@ -2,54 +2,81 @@ package meetings
 
 import (
 	"context"
+	"orkestra-api/internal/groups"
+	"orkestra-api/internal/tasks"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Els membres d'un grup en veuen les reunions i hi poden afegir reunions, participants,
+// temes i acords. Editar o esborrar és només per als administradors del grup.
 type MeetingService interface {
-	Create(ctx context.Context, request CreateMeetingRequest) (Meeting, error)
-	Update(ctx context.Context, id string, request UpdateMeetingRequest) (Meeting, error)
-	Delete(ctx context.Context, id string) error
-	FindByID(ctx context.Context, id string) (Meeting, error)
-	FindByGroupID(ctx context.Context, groupID string) ([]MeetingSummary, error)
-	FindBetweenDates(ctx context.Context, startDate, endDate string, groupID string) ([]MeetingSummary, error)
-	AddParticipant(ctx context.Context, request MeetingParticipantRequest) error
-	RemoveParticipant(ctx context.Context, id string) error
-	AddTopics(ctx context.Context, request MeetingTopicsRequest) (Topic, error)
-	RemoveTopics(ctx context.Context, id string) error
-	AddTopicAgreements(ctx context.Context, request MeetingTopicAgreementRequest) (TopicAgreement, error)
-	UpdateTopicAgreements(ctx context.Context, id string, request MeetingTopicAgreementRequest) (TopicAgreement, error)
-	RemoveTopicAgreements(ctx context.Context, id string) error
+	Create(ctx context.Context, caller groups.Caller, request CreateMeetingRequest) (Meeting, error)
+	Update(ctx context.Context, caller groups.Caller, id string, request UpdateMeetingRequest) (Meeting, error)
+	Delete(ctx context.Context, caller groups.Caller, id string, scope string) error
+	FindByID(ctx context.Context, caller groups.Caller, id string) (Meeting, error)
+	FindByGroupID(ctx context.Context, caller groups.Caller, groupID string) ([]MeetingSummary, error)
+	FindBetweenDates(ctx context.Context, caller groups.Caller, startDate, endDate string, groupID string) ([]MeetingSummary, error)
+	AddParticipant(ctx context.Context, caller groups.Caller, request MeetingParticipantRequest) error
+	RemoveParticipant(ctx context.Context, caller groups.Caller, id string) error
+	AddTopics(ctx context.Context, caller groups.Caller, request MeetingTopicsRequest) (Topic, error)
+	RemoveTopics(ctx context.Context, caller groups.Caller, id string) error
+	AddTopicAgreements(ctx context.Context, caller groups.Caller, request MeetingTopicAgreementRequest) (TopicAgreement, error)
+	UpdateTopicAgreements(ctx context.Context, caller groups.Caller, id string, request MeetingTopicAgreementRequest) (TopicAgreement, error)
+	RemoveTopicAgreements(ctx context.Context, caller groups.Caller, id string) error
+	CreateSeries(ctx context.Context, caller groups.Caller, request CreateMeetingSeriesRequest) (MeetingSeries, error)
+	FindSeriesByID(ctx context.Context, caller groups.Caller, id string) (MeetingSeries, error)
+	UpdateSeriesTemplate(ctx context.Context, caller groups.Caller, id string, request SeriesTemplateRequest) (MeetingSeries, error)
+	FindMinutes(ctx context.Context, caller groups.Caller, id string) (Minutes, error)
+	CreateAgreementTask(ctx context.Context, caller groups.Caller, id string, request AgreementTaskRequest) (tasks.Task, error)
+	FindActionItems(ctx context.Context, caller groups.Caller, id string) ([]ActionItem, error)
+	CarryOverActionItems(ctx context.Context, caller groups.Caller, id string) (CarryOver, error)
 }
 
 type meetingService struct {
 	meetingRepository MeetingRepository
+	groupService      groups.GroupService
+	taskService       tasks.TaskService
 }
 
-func NewMeetingService(meetingRepository MeetingRepository) MeetingService {
+func NewMeetingService(meetingRepository MeetingRepository, groupService groups.GroupService, taskService tasks.TaskService) MeetingService {
 	return &meetingService{
 		meetingRepository: meetingRepository,
+		groupService:      groupService,
+		taskService:       taskService,
 	}
 }
-func (s *meetingService) Create(ctx context.Context, request CreateMeetingRequest) (Meeting, error) {
+
+// Create crea la reunió en nom del caller, que ha de ser membre del grup.
+func (s *meetingService) Create(ctx context.Context, caller groups.Caller, request CreateMeetingRequest) (Meeting, error) {
+	groupID, err := uuid.Parse(request.GroupID)
+	if err != nil {
+		return Meeting{}, ErrInvalidID
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, false); err != nil {
+		return Meeting{}, err
+	}
 	meeting := Meeting{
 		ID:          uuid.New(),
 		Title:       request.Title,
 		Description: request.Description,
 		StartTime:   request.StartTime,		
-		GroupID:     uuid.MustParse(request.GroupID),
-		CreatedBy:   request.CreatedBy,
+		GroupID:     groupID,
+		CreatedBy:   caller.UserID.String(),
 		CreatedAt:   time.Now().String(),
 	}
 	return s.meetingRepository.Create(ctx, &meeting)
 }
 
-func (s *meetingService) Update(ctx context.Context, id string, request UpdateMeetingRequest) (Meeting, error) {
+func (s *meetingService) Update(ctx context.Context, caller groups.Caller, id string, request UpdateMeetingRequest) (Meeting, error) {
 	meetingID, err := uuid.Parse(id)
 	if err != nil {
 		return Meeting{}, ErrInvalidID
 	}
+	if err := s.authorizeMeeting(ctx, caller, meetingID, true); err != nil {
+		return Meeting{}, err
+	}
 
 	// Validate the request
 	if request.Title == "" || request.Description == "" || request.StartTime == "" {
@@ -61,6 +88,21 @@ func (s *meetingService) Update(ctx context.Context, id string, request UpdateMe
 		return Meeting{}, err
 	}
 
+	switch request.Scope {
+	case ScopeFollowing:
+		if meeting.SeriesID == nil {
+			return Meeting{}, ErrNotRecurring
+		}
+		return s.updateFollowing(ctx, meeting, request)
+	case "", ScopeThis:
+		// La regla només es pot canviar per a les ocurrències següents
+		if request.RRule != "" {
+			return Meeting{}, ErrInvalidRequest
+		}
+	default:
+		return Meeting{}, ErrInvalidScope
+	}
+
 	meeting.Title = request.Title
 	meeting.Description = request.Description
 	meeting.StartTime = request.StartTime
@@ -68,26 +110,44 @@ func (s *meetingService) Update(ctx context.Context, id string, request UpdateMe
 	return s.meetingRepository.Update(ctx, &meeting)
 }
 
-func (s *meetingService) Delete(ctx context.Context, id string) error {
+// Delete esborra la reunió. Amb scope "following" també acaba la sèrie i n'esborra les
+// ocurrències següents.
+func (s *meetingService) Delete(ctx context.Context, caller groups.Caller, id string, scope string) error {
 	meetingID, err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidID
 	}
 
 	// Check if the meeting exists
-	_, err = s.meetingRepository.FindByID(ctx, meetingID)
-	if err != nil {
+	if err := s.authorizeMeeting(ctx, caller, meetingID, true); err != nil {
 		return err
 	}
 
-	return s.meetingRepository.Delete(ctx, meetingID)
+	switch scope {
+	case "", ScopeThis:
+		return s.meetingRepository.Delete(ctx, meetingID)
+	case ScopeFollowing:
+		meeting, err := s.meetingRepository.FindByID(ctx, meetingID)
+		if err != nil {
+			return err
+		}
+		if meeting.SeriesID == nil {
+			return ErrNotRecurring
+		}
+		return s.deleteFollowing(ctx, meeting)
+	default:
+		return ErrInvalidScope
+	}
 }
 
-func (s *meetingService) FindByID(ctx context.Context, id string) (Meeting, error) {
+func (s *meetingService) FindByID(ctx context.Context, caller groups.Caller, id string) (Meeting, error) {
 	meetingID, err := uuid.Parse(id)
 	if err != nil {
 		return Meeting{}, ErrInvalidID
 	}
+	if err := s.authorizeMeeting(ctx, caller, meetingID, false); err != nil {
+		return Meeting{}, err
+	}
 
 	meeting, err := s.meetingRepository.FindByID(ctx, meetingID)
 	if err != nil {
@@ -97,11 +157,17 @@ func (s *meetingService) FindByID(ctx context.Context, id string) (Meeting, erro
 	return meeting, nil
 }
 
-func (s *meetingService) FindByGroupID(ctx context.Context, groupID string) ([]MeetingSummary, error) {
+func (s *meetingService) FindByGroupID(ctx context.Context, caller groups.Caller, groupID string) ([]MeetingSummary, error) {
 	groupUUID, err := uuid.Parse(groupID)
 	if err != nil {
 		return nil, ErrInvalidID
 	}
+	if err := s.groupService.Authorize(ctx, caller, groupUUID, false); err != nil {
+		return nil, err
+	}
+	if err := s.materialize(ctx, groupUUID, uuid.Nil, time.Now().Add(seriesHorizon)); err != nil {
+		return nil, err
+	}
 
 	meetings, err := s.meetingRepository.FindByGroupID(ctx, groupUUID)
 	if err != nil {
@@ -111,7 +177,8 @@ func (s *meetingService) FindByGroupID(ctx context.Context, groupID string) ([]M
 	return meetings, nil
 }
 
-func (s *meetingService) FindBetweenDates(ctx context.Context, startDate, endDate string, groupID string) ([]MeetingSummary, error) {
+// FindBetweenDates només retorna reunions dels grups del caller, tret que sigui administrador.
+func (s *meetingService) FindBetweenDates(ctx context.Context, caller groups.Caller, startDate, endDate string, groupID string) ([]MeetingSummary, error) {
 	const layoutISO = "2006-01-02"
 
 	startTime, err := time.Parse(layoutISO, startDate)
@@ -128,7 +195,21 @@ func (s *meetingService) FindBetweenDates(ctx context.Context, startDate, endDat
 		groupUUID = uuid.Nil
 	}
 
-	meetings, err := s.meetingRepository.FindBetweenDates(ctx, startTime, endTime, groupUUID)
+	memberID := caller.UserID
+	if caller.Admin {
+		memberID = uuid.Nil
+	}
+
+	// Les sèries es creen fins al final del darrer dia demanat, sense passar de seriesHorizon
+	until := endTime.AddDate(0, 0, 1)
+	if limit := time.Now().Add(seriesHorizon); until.After(limit) {
+		until = limit
+	}
+	if err := s.materialize(ctx, groupUUID, memberID, until); err != nil {
+		return nil, err
+	}
+
+	meetings, err := s.meetingRepository.FindBetweenDates(ctx, startTime, endTime, groupUUID, memberID)
 	if err != nil {
 		return nil, err
 	}
@@ -136,35 +217,60 @@ func (s *meetingService) FindBetweenDates(ctx context.Context, startDate, endDat
 	return meetings, nil
 }	
 
-func (s *meetingService) AddParticipant(ctx context.Context, request MeetingParticipantRequest) error {
-	_, err := uuid.Parse(request.MeetingID)
+// AddParticipant afegeix un participant, que ha de ser membre del grup de la reunió.
+func (s *meetingService) AddParticipant(ctx context.Context, caller groups.Caller, request MeetingParticipantRequest) error {
+	meetingID, err := uuid.Parse(request.MeetingID)
 	if err != nil {
 		return ErrInvalidID
 	}
 
-	_ , err = uuid.Parse(request.UserID)
+	userID, err := uuid.Parse(request.UserID)
 	if err != nil {
 		return ErrInvalidID
 	}
+	groupID, err := s.meetingRepository.FindGroupIDByMeetingID(ctx, meetingID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, false); err != nil {
+		return err
+	}
+	membership, err := s.groupService.Membership(ctx, groupID, userID)
+	if err != nil {
+		return err
+	}
+	if !membership.Member {
+		return ErrParticipantNotInGroup
+	}
 	id := uuid.New()
 
 	return s.meetingRepository.AddParticipant(ctx, id, request)
 }
 
-func (s *meetingService) RemoveParticipant(ctx context.Context, id string) error {	
+func (s *meetingService) RemoveParticipant(ctx context.Context, caller groups.Caller, id string) error {	
 	idUUID , err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidID
 	}
+	groupID, err := s.meetingRepository.FindGroupIDByParticipantID(ctx, idUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, true); err != nil {
+		return err
+	}
 
 	return s.meetingRepository.RemoveParticipant(ctx, idUUID)
 }
 
-func (s *meetingService) AddTopics(ctx context.Context, request MeetingTopicsRequest) (Topic, error) {
-	_, err := uuid.Parse(request.MeetingID)
+func (s *meetingService) AddTopics(ctx context.Context, caller groups.Caller, request MeetingTopicsRequest) (Topic, error) {
+	meetingID, err := uuid.Parse(request.MeetingID)
 	if err != nil {
 		return Topic{}, ErrInvalidID
 	}
+	if err := s.authorizeMeeting(ctx, caller, meetingID, false); err != nil {
+		return Topic{}, err
+	}
 
 	// Validate the request
 	if request.Title == "" {
@@ -176,50 +282,101 @@ func (s *meetingService) AddTopics(ctx context.Context, request MeetingTopicsReq
 	return s.meetingRepository.AddTopics(ctx, id, request)
 }
 
-func (s *meetingService) RemoveTopics(ctx context.Context, id string) error {
+func (s *meetingService) RemoveTopics(ctx context.Context, caller groups.Caller, id string) error {
 	idUUID , err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidID
 	}
+	groupID, err := s.meetingRepository.FindGroupIDByTopicID(ctx, idUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, true); err != nil {
+		return err
+	}
 
 	return s.meetingRepository.RemoveTopics(ctx, idUUID)
 }
 
-func (s *meetingService) AddTopicAgreements(ctx context.Context, request MeetingTopicAgreementRequest) (TopicAgreement, error) {
-	_, err := uuid.Parse(request.MeetingTopicId)
+// AddTopicAgreements afegeix l'acord en nom del caller, que ha de ser membre del grup.
+func (s *meetingService) AddTopicAgreements(ctx context.Context, caller groups.Caller, request MeetingTopicAgreementRequest) (TopicAgreement, error) {
+	topicID, err := uuid.Parse(request.MeetingTopicId)
 	if err != nil {
 		return TopicAgreement{}, ErrInvalidID
 	}
 
 	// Validate the request
-	if request.Title == "" || request.CreatedBy == "" {
+	if request.Title == "" {
 		return TopicAgreement{}, ErrInvalidRequest
 	}
+	groupID, err := s.meetingRepository.FindGroupIDByTopicID(ctx, topicID)
+	if err != nil {
+		return TopicAgreement{}, err
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, false); err != nil {
+		return TopicAgreement{}, err
+	}
+	request.CreatedBy = caller.UserID.String()
 
 	id := uuid.New()
 
 	return s.meetingRepository.AddTopicAgreements(ctx, id, request)
 }
 
-func (s *meetingService) UpdateTopicAgreements(ctx context.Context, id string, request MeetingTopicAgreementRequest) (TopicAgreement, error) {
+func (s *meetingService) UpdateTopicAgreements(ctx context.Context, caller groups.Caller, id string, request MeetingTopicAgreementRequest) (TopicAgreement, error) {
 	idUUID , err := uuid.Parse(id)
 	if err != nil {
 		return TopicAgreement{}, ErrInvalidID
 	}
 
 	// Validate the request
-	if request.Title == "" || request.CreatedBy == "" {
+	if request.Title == "" {
 		return TopicAgreement{}, ErrInvalidRequest
 	}
+	groupID, err := s.meetingRepository.FindGroupIDByAgreementID(ctx, idUUID)
+	if err != nil {
+		return TopicAgreement{}, err
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, true); err != nil {
+		return TopicAgreement{}, err
+	}
 
 	return s.meetingRepository.UpdateTopicAgreements(ctx, idUUID, request)
 }
 
-func (s *meetingService) RemoveTopicAgreements(ctx context.Context, id string) error {
+func (s *meetingService) RemoveTopicAgreements(ctx context.Context, caller groups.Caller, id string) error {
 	idUUID , err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidID
 	}
+	groupID, err := s.meetingRepository.FindGroupIDByAgreementID(ctx, idUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupService.Authorize(ctx, caller, groupID, true); err != nil {
+		return err
+	}
 
 	return s.meetingRepository.RemoveTopicAgreements(ctx, idUUID)
-}
\ No newline at end of file
+}
+
+// FindMinutes retorna les dades de l'acta. Qualsevol membre del grup la pot consultar.
+func (s *meetingService) FindMinutes(ctx context.Context, caller groups.Caller, id string) (Minutes, error) {
+	meetingID, err := uuid.Parse(id)
+	if err != nil {
+		return Minutes{}, ErrInvalidID
+	}
+	if err := s.authorizeMeeting(ctx, caller, meetingID, false); err != nil {
+		return Minutes{}, err
+	}
+	return s.meetingRepository.FindMinutes(ctx, meetingID)
+}
+
+// authorizeMeeting comprova el rol del caller al grup de la reunió.
+func (s *meetingService) authorizeMeeting(ctx context.Context, caller groups.Caller, meetingID uuid.UUID, requireAdmin bool) error {
+	groupID, err := s.meetingRepository.FindGroupIDByMeetingID(ctx, meetingID)
+	if err != nil {
+		return err
+	}
+	return s.groupService.Authorize(ctx, caller, groupID, requireAdmin)
+}
@@ -1,15 +1,19 @@
 package menus
 
-import "github.com/gin-gonic/gin"
-
-func RegisterRoutes(router *gin.RouterGroup, handler *MenuHandler) {
-	router.POST("/menus", handler.Create)
-	router.PUT("/menus/:id", handler.Update)
-	router.DELETE("/menus/:id", handler.Delete)
-	router.GET("/menus/:id", handler.GetByID)
-	router.GET("/menus", handler.GetAll)
-	router.GET("/menus/profile/:profile_id", handler.GetByProfileID)
-
-	router.POST("/menus/addprofile", handler.AddMenuToProfile)
-	router.POST("/menus/removeprofile", handler.RemoveMenuFromProfile)
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *MenuHandler, authz *middleware.Authorizer) {
+	router.POST("/menus", authz.Require("menus:write"), handler.Create)
+	router.PUT("/menus/:id", authz.Require("menus:write"), handler.Update)
+	router.DELETE("/menus/:id", authz.Require("menus:write"), handler.Delete)
+	router.GET("/menus/:id", authz.Require("menus:read"), handler.GetByID)
+	router.GET("/menus", authz.Require("menus:read"), handler.GetAll)
+	router.GET("/menus/profile/:profile_id", authz.Require("menus:read"), handler.GetByProfileID)
+
+	router.POST("/menus/addprofile", authz.Require("menus:write"), handler.AddMenuToProfile)
+	router.POST("/menus/removeprofile", authz.Require("menus:write"), handler.RemoveMenuFromProfile)
 }
\ No newline at end of file
@@ -1,8 +1,23 @@
 package operators
 
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
 type OperatorRequest struct {
 	Name    string `json:"name" binding:"required"`
 	Surname string `json:"surname"`
 	Cost    string `json:"cost" binding:"required"`
 	Color   string `json:"color" binding:"required"`
+}
+
+// OperatorResponse és l'operari tal com es retorna a l'API. El cost només hi és si
+// el perfil té el permís operators:read_cost.
+type OperatorResponse struct {
+	ID      uuid.UUID        `json:"id"`
+	Name    string           `json:"name"`
+	Surname string           `json:"surname"`
+	Cost    *decimal.Decimal `json:"cost,omitempty"`
+	Color   string           `json:"color"`
 }
\ No newline at end of file
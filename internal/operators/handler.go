@@ -2,6 +2,7 @@ package operators
 
 import (
 	"net/http"
+	"orkestra-api/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,7 +28,7 @@ func (h *OperatorHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, operator)
+	c.JSON(http.StatusCreated, operatorResponse(c, operator))
 }
 
 func (h *OperatorHandler) Update(c *gin.Context) {
@@ -42,7 +43,7 @@ func (h *OperatorHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, operator)
+	c.JSON(http.StatusCreated, operatorResponse(c, operator))
 }
 
 func (h *OperatorHandler) Delete(c *gin.Context) {
@@ -62,7 +63,7 @@ func (h *OperatorHandler) FindByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}	
-	c.JSON(http.StatusOK, operator)
+	c.JSON(http.StatusOK, operatorResponse(c, operator))
 }
 
 func (h *OperatorHandler) FindAll(c *gin.Context) {
@@ -71,6 +72,25 @@ func (h *OperatorHandler) FindAll(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, operators)
+	response := make([]OperatorResponse, 0, len(operators))
+	for _, operator := range operators {
+		response = append(response, operatorResponse(c, operator))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// operatorResponse amaga el cost als perfils sense operators:read_cost.
+func operatorResponse(c *gin.Context, operator Operator) OperatorResponse {
+	response := OperatorResponse{
+		ID:      operator.ID,
+		Name:    operator.Name,
+		Surname: operator.Surname,
+		Color:   operator.Color,
+	}
+	if middleware.HasPermission(c, "operators:read_cost") {
+		cost := operator.Cost
+		response.Cost = &cost
+	}
+	return response
 }
 
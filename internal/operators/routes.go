@@ -1,11 +1,18 @@
 package operators
 
-import "github.com/gin-gonic/gin"
-
-func RegisterRoutes(router *gin.RouterGroup, handler *OperatorHandler) {
-	router.POST("/operators", handler.Create)
-	router.PUT("/operators/:id", handler.Update)
-	router.DELETE("/operators/:id", handler.Delete)
-	router.GET("/operators/:id", handler.FindByID)
-	router.GET("/operators", handler.FindAll)
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *OperatorHandler, authz *middleware.Authorizer) {
+	// Sense aquest permís les respostes no inclouen el cost dels operaris
+	authz.Declare("operators:read_cost")
+
+	router.POST("/operators", authz.Require("operators:write"), handler.Create)
+	router.PUT("/operators/:id", authz.Require("operators:write"), handler.Update)
+	router.DELETE("/operators/:id", authz.Require("operators:write"), handler.Delete)
+	router.GET("/operators/:id", authz.Require("operators:read"), handler.FindByID)
+	router.GET("/operators", authz.Require("operators:read"), handler.FindAll)
 }
\ No newline at end of file
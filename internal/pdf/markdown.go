@@ -0,0 +1,47 @@
+package pdf
+
+import "strings"
+
+// FromMarkdown compon un document a partir d'un subconjunt de Markdown: títols "#", "##"
+// i "###", elements de llista "-" o "*" i paràgrafs. Les marques de negreta "**" es
+// treuen, i les línies seguides d'un mateix paràgraf s'ajunten.
+func FromMarkdown(markdown string) *Document {
+	doc := New()
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			doc.Text(strings.Join(paragraph, " "), Body)
+			paragraph = nil
+		}
+	}
+
+	for _, raw := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(strings.ReplaceAll(raw, "**", ""))
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "### "):
+			flush()
+			doc.Text(line[4:], Sub)
+		case strings.HasPrefix(line, "## "):
+			flush()
+			doc.Text(line[3:], Heading)
+		case strings.HasPrefix(line, "# "):
+			flush()
+			doc.Text(line[2:], Title)
+			doc.Space(4)
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "):
+			flush()
+			style := Item
+			// Les llistes niades es sagnen una mica més
+			if indent := len(raw) - len(strings.TrimLeft(raw, " ")); indent >= 2 {
+				style.Indent += 14
+			}
+			doc.Text(line[2:], style)
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return doc
+}
@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mides en punts d'un A4 i dels marges.
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	margin       = 56.0
+	contentWidth = pageWidth - 2*margin
+)
+
+// Style és el format d'un bloc de text.
+type Style struct {
+	Size   float64
+	Bold   bool
+	Indent float64
+	// Before és l'espai en punts abans del bloc
+	Before float64
+	// Bullet dibuixa una pic a l'esquerra de la primera línia
+	Bullet bool
+}
+
+// Estils predefinits per a títols, text i llistes.
+var (
+	Title   = Style{Size: 18, Bold: true, Before: 0}
+	Heading = Style{Size: 14, Bold: true, Before: 14}
+	Sub     = Style{Size: 12, Bold: true, Before: 10}
+	Body    = Style{Size: 10.5, Before: 4}
+	Item    = Style{Size: 10.5, Indent: 14, Before: 2, Bullet: true}
+)
+
+// Document és un PDF de text amb les fonts estàndard Helvetica i Helvetica-Bold, que no
+// cal incrustar. Els caràcters fora de WinAnsi (CP1252) es substitueixen per "?".
+type Document struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+// Text afegeix un paràgraf amb l'estil donat, partint-lo en línies i en pàgines.
+func (d *Document) Text(text string, style Style) {
+	if d.y < pageHeight-margin {
+		d.y -= style.Before
+	}
+	lineHeight := style.Size * 1.35
+	width := contentWidth - style.Indent
+	for i, line := range wrap(encode(text), style.Size, style.Bold, width) {
+		if d.y-lineHeight < margin {
+			d.newPage()
+		}
+		d.y -= lineHeight
+		page := d.pages[len(d.pages)-1]
+		font := "F1"
+		if style.Bold {
+			font = "F2"
+		}
+		x := margin + style.Indent
+		if style.Bullet && i == 0 {
+			fmt.Fprintf(page, "BT /F1 %.2f Tf %.2f %.2f Td (%s) Tj ET\n", style.Size, x-10, d.y, escape([]byte{0x95}))
+		}
+		fmt.Fprintf(page, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, style.Size, x, d.y, escape(line))
+	}
+}
+
+// Space deixa un espai vertical en punts.
+func (d *Document) Space(points float64) {
+	d.y -= points
+}
+
+// WriteTo escriu el document en format PDF 1.4.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1: catàleg, 2: arbre de pàgines, 3 i 4: fonts, i després pàgina i contingut per parelles
+	pageCount := len(d.pages)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+// wrap parteix el text en línies que caben a width, tallant per espais. Una paraula més
+// llarga que la línia es talla on calgui.
+func wrap(text []byte, size float64, bold bool, width float64) [][]byte {
+	var lines [][]byte
+	for _, paragraph := range bytes.Split(text, []byte("\n")) {
+		var line []byte
+		for _, word := range bytes.Fields(paragraph) {
+			candidate := word
+			if len(line) > 0 {
+				candidate = append(append(append([]byte{}, line...), ' '), word...)
+			}
+			if textWidth(candidate, size, bold) <= width {
+				line = candidate
+				continue
+			}
+			if len(line) > 0 {
+				lines = append(lines, line)
+			}
+			for textWidth(word, size, bold) > width {
+				cut := 1
+				for cut < len(word) && textWidth(word[:cut+1], size, bold) <= width {
+					cut++
+				}
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func textWidth(text []byte, size float64, bold bool) float64 {
+	total := 0
+	for _, c := range text {
+		total += glyphWidth(c, bold)
+	}
+	return float64(total) * size / 1000
+}
+
+// escape protegeix els caràcters especials d'una cadena literal de PDF.
+func escape(text []byte) string {
+	var b strings.Builder
+	for _, c := range text {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
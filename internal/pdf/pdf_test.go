@@ -0,0 +1,204 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var startxrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+
+// checkStructure comprova que la taula xref apunta a l'inici de cada objecte i que l'arbre
+// de pàgines en compta tantes com objectes /Page hi ha. Retorna el nombre de pàgines.
+func checkStructure(t *testing.T, data []byte) int {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header: %q", data[:min(len(data), 16)])
+	}
+	match := startxrefPattern.FindSubmatch(data)
+	if match == nil {
+		t.Fatalf("missing startxref trailer")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d does not point to the xref table", xref)
+	}
+
+	lines := strings.Split(string(data[xref:]), "\n")
+	size, err := strconv.Atoi(strings.TrimPrefix(lines[1], "0 "))
+	if err != nil {
+		t.Fatalf("xref subsection header %q: %v", lines[1], err)
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Errorf("xref entry 0 = %q", lines[2])
+	}
+	for n := 1; n < size; n++ {
+		entry := lines[2+n]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("xref entry %d = %q", n, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", n); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points to %q, want %q", n, data[offset:min(len(data), offset+12)], want)
+		}
+	}
+	if !bytes.Contains(data, []byte(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>", size))) {
+		t.Errorf("trailer /Size does not match the xref table (%d)", size)
+	}
+
+	count := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(data)
+	if count == nil {
+		t.Fatalf("missing page tree")
+	}
+	pages, _ := strconv.Atoi(string(count[1]))
+	if objects := bytes.Count(data, []byte("<< /Type /Page /Parent")); objects != pages {
+		t.Errorf("page tree /Count = %d, but there are %d page objects", pages, objects)
+	}
+	// Catàleg, arbre de pàgines, dues fonts i una pàgina i un contingut per pàgina
+	if size != 5+2*pages {
+		t.Errorf("xref has %d entries, want %d for %d pages", size, 5+2*pages, pages)
+	}
+	return pages
+}
+
+func render(t *testing.T, doc *Document) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	n, err := doc.WriteTo(&out)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if n != int64(out.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", n, out.Len())
+	}
+	return out.Bytes()
+}
+
+func TestWriteToSplitsPages(t *testing.T) {
+	// Amb cos 10.5 caben 51 línies per pàgina: 120 línies en fan tres
+	lines := make([]string, 120)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("Línia %d", i+1)
+	}
+	doc := New()
+	doc.Text(strings.Join(lines, "\n"), Body)
+
+	data := render(t, doc)
+	if pages := checkStructure(t, data); pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	for _, want := range []string{"(L\xednia 1) Tj", "(L\xednia 51) Tj", "(L\xednia 52) Tj", "(L\xednia 120) Tj"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	// La línia 52 ha d'obrir la segona pàgina (contingut a l'objecte 8), a dalt de tot
+	start := bytes.Index(data, []byte("8 0 obj"))
+	end := bytes.Index(data, []byte("9 0 obj"))
+	if line := bytes.Index(data, []byte("(L\xednia 52)")); line < start || line > end {
+		t.Errorf("line 52 is not on the second page")
+	}
+	if !bytes.Contains(data, []byte("56.00 771.83 Td (L\xednia 52) Tj")) {
+		t.Errorf("line 52 is not at the top of its page")
+	}
+}
+
+func TestWriteToEmptyDocument(t *testing.T) {
+	if pages := checkStructure(t, render(t, New())); pages != 1 {
+		t.Errorf("pages = %d, want 1", pages)
+	}
+}
+
+func TestTextEscapesAndEncodes(t *testing.T) {
+	doc := New()
+	doc.Text(`Pressupost (revisat) a C:\actes amb l'àrea de l'Ona · 10 € ✓`, Body)
+
+	data := render(t, doc)
+	checkStructure(t, data)
+	want := "(Pressupost \\(revisat\\) a C:\\\\actes amb l'\xe0rea de l'Ona \xb7 10 \x80 ?) Tj"
+	if !bytes.Contains(data, []byte(want)) {
+		t.Errorf("content stream does not contain %q", want)
+	}
+	// /Length ha de ser la mida exacta del contingut, amb les barres afegides
+	stream := regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`).FindSubmatchIndex(data)
+	length, _ := strconv.Atoi(string(data[stream[2]:stream[3]]))
+	if !bytes.HasPrefix(data[stream[1]+length:], []byte("endstream")) {
+		t.Errorf("/Length %d does not end at endstream", length)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Reunió", "Reuni\xf3"},
+		{"Çà l·l", "\xc7\xe0 l\xb7l"},
+		{"“cometes” – guió…", "\x93cometes\x94 \x96 gui\xf3\x85"},
+		{"a\tb\r\nc", "a b \nc"},
+		{"日本 🙂", "?? ?"},
+	}
+	for _, test := range tests {
+		if got := string(encode(test.in)); got != test.want {
+			t.Errorf("encode(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	text := encode("Les actes de la reunió s'envien a tots els participants l'endemà " + strings.Repeat("x", 120))
+	width := 200.0
+	lines := wrap(text, 10, false, width)
+	if len(lines) < 3 {
+		t.Fatalf("lines = %q", lines)
+	}
+	var joined []byte
+	for _, line := range lines {
+		if w := textWidth(line, 10, false); w > width {
+			t.Errorf("line %q is %.1f points wide, more than %.1f", line, w, width)
+		}
+		joined = append(joined, line...)
+	}
+	// Només es perden els espais on es talla la línia
+	if want := bytes.ReplaceAll(text, []byte(" "), nil); !bytes.Equal(bytes.ReplaceAll(joined, []byte(" "), nil), want) {
+		t.Errorf("wrapped text = %q", joined)
+	}
+	if got := wrap(encode("a\n\nb"), 10, false, width); len(got) != 3 || len(got[1]) != 0 {
+		t.Errorf("blank lines are not kept: %q", got)
+	}
+}
+
+func TestFromMarkdown(t *testing.T) {
+	data := render(t, FromMarkdown("# Acta\r\n\n**Data:** avui\n\n## Participants\n- Anna\n  - Joan\n\nUna línia\ni una altra\n### Tema"))
+	checkStructure(t, data)
+
+	for _, want := range []string{
+		"BT /F2 18.00 Tf 56.00 761.70 Td (Acta) Tj ET",
+		"BT /F1 10.50 Tf",
+		"(Data: avui) Tj",
+		"BT /F2 14.00 Tf",
+		"(Participants) Tj",
+		"(Una l\xednia i una altra) Tj",
+		"BT /F2 12.00 Tf",
+		"(Tema) Tj",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	// Els elements de llista es sagnen 14 punts, i 14 més si són niats
+	for _, pattern := range []string{`Tf 70\.00 [\d.]+ Td \(Anna\) Tj`, `Tf 84\.00 [\d.]+ Td \(Joan\) Tj`} {
+		if !regexp.MustCompile(pattern).Match(data) {
+			t.Errorf("missing %s", pattern)
+		}
+	}
+	if bullets := bytes.Count(data, []byte("(\x95) Tj")); bullets != 2 {
+		t.Errorf("bullets = %d, want 2", bullets)
+	}
+	if bytes.Contains(data, []byte("**")) {
+		t.Errorf("bold markers were not removed")
+	}
+}
@@ -1,5 +1,11 @@
 package profiles
 
 type ProfileRequest struct {
-	Name string `json:"name" binding:"required"`
-}
\ No newline at end of file
+	Name             string `json:"name" binding:"required"`
+	IsAdmin          bool   `json:"is_admin"`
+	RequireTwoFactor bool   `json:"require_2fa"`
+}
+
+type PermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
@@ -0,0 +1,11 @@
+package profiles
+
+import "errors"
+
+var (
+	ErrInvalidProfileID  = errors.New("invalid profile ID")
+	ErrProfileNotFound   = errors.New("profile not found")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrProfileInUse      = errors.New("profile is assigned to users or menus")
+	ErrNoAdminProfile    = errors.New("no admin profile: set ADMIN_PROFILE_ID to the profile that manages permissions")
+)
@@ -0,0 +1,117 @@
+package profiles
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	service ProfileService
+}
+
+func NewProfileHandler(service ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		service: service,
+	}
+}
+
+func (h *ProfileHandler) Create(c *gin.Context) {
+	var request ProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	profile, err := h.service.Create(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, profile)
+}
+
+func (h *ProfileHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	var request ProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	profile, err := h.service.Update(c.Request.Context(), id, request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ProfileHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *ProfileHandler) GetByID(c *gin.Context) {
+	id := c.Param("id")
+	profile, err := h.service.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ProfileHandler) GetAll(c *gin.Context) {
+	profiles, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profiles)
+}
+
+func (h *ProfileHandler) GetAllPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.FindAllPermissions(c.Request.Context()))
+}
+
+func (h *ProfileHandler) GetPermissions(c *gin.Context) {
+	id := c.Param("id")
+	permissions, err := h.service.FindPermissions(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, permissions)
+}
+
+func (h *ProfileHandler) SetPermissions(c *gin.Context) {
+	id := c.Param("id")
+	var request PermissionsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	permissions, err := h.service.SetPermissions(c.Request.Context(), id, request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, permissions)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidProfileID), errors.Is(err, ErrUnknownPermission):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrProfileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrProfileInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,6 +1,24 @@
 package profiles
 
+import "orkestra-api/internal/menus"
+
 type Profile struct {
-	ID   string `json:"id" db:"id"`
-	Name string `json:"name" db:"name"`
-}
\ No newline at end of file
+	ID      string `json:"id" db:"id"`
+	Name    string `json:"name" db:"name"`
+	IsAdmin bool   `json:"is_admin" db:"is_admin"`
+	// RequireTwoFactor obliga els usuaris del perfil a fer login amb segon factor
+	RequireTwoFactor bool `json:"require_2fa" db:"require_2fa"`
+}
+
+// ProfileDetail és el perfil amb els menús assignats i el nombre d'usuaris que el fan servir.
+type ProfileDetail struct {
+	Profile
+	Menus     []menus.MenuTree `json:"menus"`
+	UserCount int              `json:"user_count"`
+}
+
+type ProfilePermissions struct {
+	ProfileID   string   `json:"profile_id"`
+	IsAdmin     bool     `json:"is_admin"`
+	Permissions []string `json:"permissions"`
+}
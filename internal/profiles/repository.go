@@ -0,0 +1,227 @@
+package profiles
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type ProfileRepository interface {
+	Create(ctx context.Context, profile Profile) (Profile, error)
+	Update(ctx context.Context, profile Profile) (Profile, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (Profile, error)
+	FindAll(ctx context.Context) ([]Profile, error)
+	CountUsers(ctx context.Context, id uuid.UUID) (int, error)
+	CountMenus(ctx context.Context, id uuid.UUID) (int, error)
+	FindPermissions(ctx context.Context, profileID uuid.UUID) (ProfilePermissions, error)
+	SetPermissions(ctx context.Context, profileID uuid.UUID, permissions []string) error
+	SetAdmin(ctx context.Context, id uuid.UUID) error
+	CountAdmins(ctx context.Context) (int, error)
+}
+
+type profileRepository struct {
+	db *sql.DB
+}
+
+func NewProfileRepository(db *sql.DB) ProfileRepository {
+	return &profileRepository{db: db}
+}
+
+func (r *profileRepository) Create(ctx context.Context, profile Profile) (Profile, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO profiles (id, name, is_admin, require_2fa)
+		VALUES ($1, $2, $3, $4)`,
+		profile.ID, profile.Name, profile.IsAdmin, profile.RequireTwoFactor,
+	)
+	if err != nil {
+		return Profile{}, err
+	}
+	return profile, nil
+}
+
+func (r *profileRepository) Update(ctx context.Context, profile Profile) (Profile, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE profiles
+		SET name = $1, is_admin = $2, require_2fa = $3
+		WHERE id = $4`,
+		profile.Name, profile.IsAdmin, profile.RequireTwoFactor, profile.ID,
+	)
+	if err != nil {
+		return Profile{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return Profile{}, err
+	}
+	if affected == 0 {
+		return Profile{}, sql.ErrNoRows
+	}
+	return profile, nil
+}
+
+func (r *profileRepository) SetAdmin(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE profiles
+		SET is_admin = TRUE
+		WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *profileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM profiles
+		WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+func (r *profileRepository) FindByID(ctx context.Context, id uuid.UUID) (Profile, error) {
+	var profile Profile
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, COALESCE(name, ''), is_admin, require_2fa
+		FROM profiles
+		WHERE id = $1`,
+		id,
+	).Scan(&profile.ID, &profile.Name, &profile.IsAdmin, &profile.RequireTwoFactor)
+	if err != nil {
+		return Profile{}, err
+	}
+	return profile, nil
+}
+
+func (r *profileRepository) FindAll(ctx context.Context) ([]Profile, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, COALESCE(name, ''), is_admin, require_2fa
+		FROM profiles
+		ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	for rows.Next() {
+		var profile Profile
+		if err := rows.Scan(&profile.ID, &profile.Name, &profile.IsAdmin, &profile.RequireTwoFactor); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *profileRepository) CountAdmins(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM profiles
+		WHERE is_admin`,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *profileRepository) CountUsers(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM users
+		WHERE profile_id = $1`,
+		id,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *profileRepository) CountMenus(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM profile_menus
+		WHERE profile_id = $1`,
+		id,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *profileRepository) FindPermissions(ctx context.Context, profileID uuid.UUID) (ProfilePermissions, error) {
+	result := ProfilePermissions{ProfileID: profileID.String(), Permissions: []string{}}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT is_admin
+		FROM profiles
+		WHERE id = $1`,
+		profileID,
+	).Scan(&result.IsAdmin)
+	if err != nil {
+		return ProfilePermissions{}, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT permission
+		FROM profile_permissions
+		WHERE profile_id = $1
+		ORDER BY permission`,
+		profileID,
+	)
+	if err != nil {
+		return ProfilePermissions{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return ProfilePermissions{}, err
+		}
+		result.Permissions = append(result.Permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return ProfilePermissions{}, err
+	}
+	return result, nil
+}
+
+// SetPermissions substitueix tots els permisos del perfil dins d'una transacció.
+func (r *profileRepository) SetPermissions(ctx context.Context, profileID uuid.UUID, permissions []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM profile_permissions
+		WHERE profile_id = $1`,
+		profileID,
+	)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO profile_permissions (profile_id, permission)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`,
+			profileID, permission,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
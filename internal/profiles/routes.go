@@ -0,0 +1,18 @@
+package profiles
+
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *ProfileHandler, authz *middleware.Authorizer) {
+	router.POST("/profiles", authz.Require("profiles:admin"), handler.Create)
+	router.PUT("/profiles/:id", authz.Require("profiles:admin"), handler.Update)
+	router.DELETE("/profiles/:id", authz.Require("profiles:admin"), handler.Delete)
+	router.GET("/profiles/:id", authz.Require("profiles:read"), handler.GetByID)
+	router.GET("/profiles", authz.Require("profiles:read"), handler.GetAll)
+	router.GET("/profiles/permissions", authz.Require("profiles:admin"), handler.GetAllPermissions)
+	router.GET("/profiles/:id/permissions", authz.Require("profiles:admin"), handler.GetPermissions)
+	router.PUT("/profiles/:id/permissions", authz.Require("profiles:admin"), handler.SetPermissions)
+}
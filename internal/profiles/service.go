@@ -0,0 +1,188 @@
+package profiles
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"orkestra-api/internal/menus"
+
+	"github.com/google/uuid"
+)
+
+// PermissionCatalog coneix els permisos que declaren les rutes (middleware.Authorizer).
+type PermissionCatalog interface {
+	Declared() []string
+	IsDeclared(permission string) bool
+}
+
+type ProfileService interface {
+	Create(ctx context.Context, request ProfileRequest) (Profile, error)
+	Update(ctx context.Context, id string, request ProfileRequest) (Profile, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (ProfileDetail, error)
+	FindAll(ctx context.Context) ([]Profile, error)
+	FindAllPermissions(ctx context.Context) []string
+	FindPermissions(ctx context.Context, id string) (ProfilePermissions, error)
+	SetPermissions(ctx context.Context, id string, request PermissionsRequest) (ProfilePermissions, error)
+	EnsureAdmin(ctx context.Context, id string) error
+}
+
+type profileService struct {
+	repo        ProfileRepository
+	menuService menus.MenuService
+	catalog     PermissionCatalog
+}
+
+func NewProfileService(repo ProfileRepository, menuService menus.MenuService, catalog PermissionCatalog) ProfileService {
+	return &profileService{repo: repo, menuService: menuService, catalog: catalog}
+}
+
+func (s *profileService) Create(ctx context.Context, request ProfileRequest) (Profile, error) {
+	profile := Profile{
+		ID:               uuid.New().String(),
+		Name:             request.Name,
+		IsAdmin:          request.IsAdmin,
+		RequireTwoFactor: request.RequireTwoFactor,
+	}
+	return s.repo.Create(ctx, profile)
+}
+
+func (s *profileService) Update(ctx context.Context, id string, request ProfileRequest) (Profile, error) {
+	profileUUID, err := uuid.Parse(id)
+	if err != nil {
+		return Profile{}, ErrInvalidProfileID
+	}
+	profile, err := s.repo.Update(ctx, Profile{
+		ID:               profileUUID.String(),
+		Name:             request.Name,
+		IsAdmin:          request.IsAdmin,
+		RequireTwoFactor: request.RequireTwoFactor,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Profile{}, ErrProfileNotFound
+	}
+	return profile, err
+}
+
+// EnsureAdmin marca el perfil id com a perfil d'administració, si n'hi ha, i comprova que
+// n'hi hagi almenys un. Es crida a l'arrencada amb ADMIN_PROFILE_ID perquè sempre hi hagi
+// algú que pugui gestionar perfils i permisos.
+func (s *profileService) EnsureAdmin(ctx context.Context, id string) error {
+	if id != "" {
+		profileUUID, err := uuid.Parse(id)
+		if err != nil {
+			return ErrInvalidProfileID
+		}
+		err = s.repo.SetAdmin(ctx, profileUUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProfileNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	admins, err := s.repo.CountAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if admins == 0 {
+		return ErrNoAdminProfile
+	}
+	return nil
+}
+
+// Delete només esborra el perfil si cap usuari ni cap menú hi fa referència.
+// Els permisos del perfil s'esborren en cascada.
+func (s *profileService) Delete(ctx context.Context, id string) error {
+	profileUUID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidProfileID
+	}
+	if _, err := s.repo.FindByID(ctx, profileUUID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProfileNotFound
+		}
+		return err
+	}
+	users, err := s.repo.CountUsers(ctx, profileUUID)
+	if err != nil {
+		return err
+	}
+	menuCount, err := s.repo.CountMenus(ctx, profileUUID)
+	if err != nil {
+		return err
+	}
+	if users > 0 || menuCount > 0 {
+		return ErrProfileInUse
+	}
+	return s.repo.Delete(ctx, profileUUID)
+}
+
+func (s *profileService) FindByID(ctx context.Context, id string) (ProfileDetail, error) {
+	profileUUID, err := uuid.Parse(id)
+	if err != nil {
+		return ProfileDetail{}, ErrInvalidProfileID
+	}
+	profile, err := s.repo.FindByID(ctx, profileUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ProfileDetail{}, ErrProfileNotFound
+		}
+		return ProfileDetail{}, err
+	}
+	profileMenus, err := s.menuService.FindByProfileID(ctx, id)
+	if err != nil {
+		return ProfileDetail{}, err
+	}
+	if profileMenus == nil {
+		profileMenus = []menus.MenuTree{}
+	}
+	users, err := s.repo.CountUsers(ctx, profileUUID)
+	if err != nil {
+		return ProfileDetail{}, err
+	}
+	return ProfileDetail{Profile: profile, Menus: profileMenus, UserCount: users}, nil
+}
+
+func (s *profileService) FindAll(ctx context.Context) ([]Profile, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *profileService) FindAllPermissions(ctx context.Context) []string {
+	return s.catalog.Declared()
+}
+
+func (s *profileService) FindPermissions(ctx context.Context, id string) (ProfilePermissions, error) {
+	profileUUID, err := uuid.Parse(id)
+	if err != nil {
+		return ProfilePermissions{}, ErrInvalidProfileID
+	}
+	permissions, err := s.repo.FindPermissions(ctx, profileUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProfilePermissions{}, ErrProfileNotFound
+	}
+	return permissions, err
+}
+
+func (s *profileService) SetPermissions(ctx context.Context, id string, request PermissionsRequest) (ProfilePermissions, error) {
+	profileUUID, err := uuid.Parse(id)
+	if err != nil {
+		return ProfilePermissions{}, ErrInvalidProfileID
+	}
+	for _, permission := range request.Permissions {
+		if !s.catalog.IsDeclared(permission) {
+			return ProfilePermissions{}, fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+	}
+	if _, err := s.repo.FindByID(ctx, profileUUID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ProfilePermissions{}, ErrProfileNotFound
+		}
+		return ProfilePermissions{}, err
+	}
+	if err := s.repo.SetPermissions(ctx, profileUUID, request.Permissions); err != nil {
+		return ProfilePermissions{}, err
+	}
+	return s.repo.FindPermissions(ctx, profileUUID)
+}
@@ -1,5 +1,12 @@
 package projects
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
 type ProjectRequest struct {
 	Description   string `json:"description" binding:"required"`
 	StartDate     string `json:"start_date" binding:"required"`
@@ -33,4 +40,16 @@ type CostItemRequest struct {
 	ShortDescription string `json:"short_description" binding:"required"`
 	Notes            string `json:"notes" binding:"required"`
 	Date             string `json:"date" binding:"required"`
-}
\ No newline at end of file
+}
+
+// OperatorToProjectResponse és l'assignació tal com es retorna a l'API. El cost només hi
+// és si el perfil té el permís operators:read_cost.
+type OperatorToProjectResponse struct {
+	ID                uuid.UUID        `json:"id"`
+	OperatorID        uuid.UUID        `json:"operator_id"`
+	ProjectID         uuid.UUID        `json:"project_id"`
+	Cost              *decimal.Decimal `json:"cost,omitempty"`
+	DedicationPercent decimal.Decimal  `json:"dedication_percent"`
+	StartDate         time.Time        `json:"start_date"`
+	EndDate           time.Time        `json:"end_date"`
+}
@@ -2,6 +2,8 @@ package projects
 
 import (
 	"net/http"
+	"orkestra-api/internal/ical"
+	"orkestra-api/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -120,6 +122,10 @@ func(h *ProjectHandler)GetProjectsCalendarBetweenDates(c *gin.Context){
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if c.Query("format") == "ics" {
+		ical.Render(c, "projects.ics", ical.Calendar{Name: "Orkestra - Projectes", Events: ProjectEvents(data)})
+		return
+	}
 	c.JSON(http.StatusOK, data)
 }
 
@@ -134,7 +140,7 @@ func (h *ProjectHandler) AddOperatorToProject(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, operators)
+	c.JSON(http.StatusCreated, operatorsResponse(c, operators))
 }
 
 func (h *ProjectHandler) RemoveOperatorFromProject(c *gin.Context) {
@@ -144,7 +150,7 @@ func (h *ProjectHandler) RemoveOperatorFromProject(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, operators)
+	c.JSON(http.StatusOK, operatorsResponse(c, operators))
 }
 
 func (h *ProjectHandler) GetOperatorsByProjectID(c *gin.Context) {
@@ -154,7 +160,29 @@ func (h *ProjectHandler) GetOperatorsByProjectID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, operators)
+	c.JSON(http.StatusOK, operatorsResponse(c, operators))
+}
+
+// operatorsResponse amaga el cost de les assignacions als perfils sense operators:read_cost.
+func operatorsResponse(c *gin.Context, operators []OperatorToProject) []OperatorToProjectResponse {
+	readCost := middleware.HasPermission(c, "operators:read_cost")
+	response := make([]OperatorToProjectResponse, 0, len(operators))
+	for _, operator := range operators {
+		item := OperatorToProjectResponse{
+			ID:                operator.ID,
+			OperatorID:        operator.OperatorID,
+			ProjectID:         operator.ProjectID,
+			DedicationPercent: operator.DedicationPercent,
+			StartDate:         operator.StartDate,
+			EndDate:           operator.EndDate,
+		}
+		if readCost {
+			cost := operator.Cost
+			item.Cost = &cost
+		}
+		response = append(response, item)
+	}
+	return response
 }
 
 func(h *ProjectHandler)GetOperatorsCalendarBetweenDates(c *gin.Context){
@@ -169,6 +197,10 @@ func(h *ProjectHandler)GetOperatorsCalendarBetweenDates(c *gin.Context){
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if c.Query("format") == "ics" {
+		ical.Render(c, "operators.ics", ical.Calendar{Name: "Orkestra - Operaris", Events: OperatorEvents(data)})
+		return
+	}
 
 	c.JSON(http.StatusOK, data)
 }
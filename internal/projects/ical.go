@@ -0,0 +1,47 @@
+package projects
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"orkestra-api/internal/ical"
+)
+
+// ProjectEvents converteix el calendari de projectes en esdeveniments de dia sencer.
+func ProjectEvents(entries []ProjectCalendarResponse) []ical.Event {
+	return calendarEvents(entries, func(entry ProjectCalendarResponse) string {
+		return "project-" + entry.ID + "@orkestra"
+	}, "Projectes")
+}
+
+// OperatorEvents converteix les assignacions d'operaris en esdeveniments de dia sencer.
+// L'ID és el del projecte, que es repeteix per a cada assignació, i per això l'UID es
+// calcula a partir de tota la fila.
+func OperatorEvents(entries []ProjectCalendarResponse) []ical.Event {
+	return calendarEvents(entries, func(entry ProjectCalendarResponse) string {
+		sum := sha256.Sum256([]byte(entry.ID + "|" + entry.Title + "|" + entry.StartDate + "|" + entry.EndDate))
+		return "operator-" + hex.EncodeToString(sum[:8]) + "@orkestra"
+	}, "Operaris")
+}
+
+func calendarEvents(entries []ProjectCalendarResponse, uid func(ProjectCalendarResponse) string, category string) []ical.Event {
+	events := make([]ical.Event, 0, len(entries))
+	for _, entry := range entries {
+		start, err := ical.ParseTime(entry.StartDate)
+		if err != nil {
+			continue
+		}
+		end, err := ical.ParseTime(entry.EndDate)
+		if err != nil {
+			end = start
+		}
+		events = append(events, ical.Event{
+			UID:        uid(entry),
+			Summary:    entry.Title,
+			Categories: []string{category},
+			Start:      start,
+			End:        end,
+			AllDay:     true,
+		})
+	}
+	return events
+}
@@ -1,20 +1,24 @@
 package projects
 
-import "github.com/gin-gonic/gin"
+import (
+	"orkestra-api/middleware"
 
-func RegisterRoutes(router *gin.RouterGroup, handler *ProjectHandler) {
-	router.POST("/projects", handler.CreateProject)
-	router.PUT("/projects/:id", handler.UpdateProject)
-	router.DELETE("/projects/:id", handler.DeleteProject)
-	router.GET("/projects/:id", handler.GetProjectByID)
-	router.GET("/projects", handler.GetAllProjects)
-	router.GET("/projects/dates", handler.GetProjectsBetweenDates)
-	router.GET("/projects/calendar/dates", handler.GetProjectsCalendarBetweenDates)
-	router.POST("/projects/operators", handler.AddOperatorToProject)
-	router.DELETE("/projects/operators/:id", handler.RemoveOperatorFromProject)
-	router.GET("/projects/operators/:project_id", handler.GetOperatorsByProjectID)
-	router.GET("/projects/operators/calendar/dates", handler.GetOperatorsCalendarBetweenDates)
-	router.POST("/projects/costitems", handler.AddCostItem)
-	router.DELETE("/projects/costitems/:id", handler.RemoveCostItem)
-	router.GET("/projects/costitems/project/:project_id", handler.GetCostItemByProjectID)
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *ProjectHandler, authz *middleware.Authorizer) {
+	router.POST("/projects", authz.Require("projects:write"), handler.CreateProject)
+	router.PUT("/projects/:id", authz.Require("projects:write"), handler.UpdateProject)
+	router.DELETE("/projects/:id", authz.Require("projects:write"), handler.DeleteProject)
+	router.GET("/projects/:id", authz.Require("projects:read"), handler.GetProjectByID)
+	router.GET("/projects", authz.Require("projects:read"), handler.GetAllProjects)
+	router.GET("/projects/dates", authz.Require("projects:read"), handler.GetProjectsBetweenDates)
+	router.GET("/projects/calendar/dates", authz.Require("projects:read"), handler.GetProjectsCalendarBetweenDates)
+	router.POST("/projects/operators", authz.Require("projects:write"), handler.AddOperatorToProject)
+	router.DELETE("/projects/operators/:id", authz.Require("projects:write"), handler.RemoveOperatorFromProject)
+	router.GET("/projects/operators/:project_id", authz.Require("projects:read"), handler.GetOperatorsByProjectID)
+	router.GET("/projects/operators/calendar/dates", authz.Require("projects:read"), handler.GetOperatorsCalendarBetweenDates)
+	router.POST("/projects/costitems", authz.Require("projects:write"), handler.AddCostItem)
+	router.DELETE("/projects/costitems/:id", authz.Require("projects:write"), handler.RemoveCostItem)
+	router.GET("/projects/costitems/project/:project_id", authz.Require("projects:read"), handler.GetCostItemByProjectID)
 }
@@ -1,7 +1,11 @@
 package searches
 
-import "github.com/gin-gonic/gin"
+import (
+	"orkestra-api/middleware"
 
-func RegisterRoutes(router *gin.RouterGroup, handler *SearchHandler){
-	router.POST("/search", handler.GetByText)
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *SearchHandler, authz *middleware.Authorizer){
+	router.POST("/search", authz.Require("search:read"), handler.GetByText)
 }
\ No newline at end of file
@@ -0,0 +1,9 @@
+package sessions
+
+import "errors"
+
+var (
+	ErrInvalidID       = errors.New("invalid session ID")
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionExpired  = errors.New("session expired or revoked")
+)
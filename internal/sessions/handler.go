@@ -0,0 +1,68 @@
+package sessions
+
+import (
+	"errors"
+	"net/http"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	service SessionService
+}
+
+func NewSessionHandler(service SessionService) *SessionHandler {
+	return &SessionHandler{
+		service: service,
+	}
+}
+
+func (h *SessionHandler) GetMySessions(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	currentID, _ := jwt.ExtractClaims(c)["jti"].(string)
+	sessions, err := h.service.FindByUserID(c.Request.Context(), userID.(string), currentID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *SessionHandler) DeleteMySession(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	if err := h.service.Revoke(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// DeleteUserSessions tanca totes les sessions d'un usuari (tancar sessió a tot arreu).
+func (h *SessionHandler) DeleteUserSessions(c *gin.Context) {
+	revoked, err := h.service.RevokeAll(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrSessionNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
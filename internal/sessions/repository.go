@@ -0,0 +1,117 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session Session) (Session, error)
+	FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	Extend(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	Revoke(ctx context.Context, id, userID uuid.UUID) error
+	RevokeByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session Session) (Session, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+		RETURNING created_at, last_seen_at`,
+		session.ID, session.UserID, session.UserAgent, session.IP, session.ExpiresAt,
+	).Scan(&session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func (r *sessionRepository) FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, COALESCE(user_agent, ''), COALESCE(ip, ''), created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Extend allarga una sessió vigent. Retorna sql.ErrNoRows si ja ha caducat o s'ha revocat.
+func (r *sessionRepository) Extend(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE sessions
+		SET expires_at = $2, last_seen_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()`,
+		id, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// Revoke revoca la sessió id només si és de l'usuari userID.
+func (r *sessionRepository) Revoke(ctx context.Context, id, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+func (r *sessionRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func expectRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package sessions
+
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *SessionHandler, authz *middleware.Authorizer) {
+	// Les sessions pròpies no necessiten cap permís, però sí una sessió d'usuari
+	router.GET("/me/sessions", middleware.RejectAPITokens, handler.GetMySessions)
+	router.DELETE("/me/sessions/:id", middleware.RejectAPITokens, handler.DeleteMySession)
+	router.DELETE("/users/:id/sessions", authz.Require("users:write"), handler.DeleteUserSessions)
+}
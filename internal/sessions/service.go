@@ -0,0 +1,100 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionService interface {
+	Start(ctx context.Context, userID uuid.UUID, userAgent, ip string) (Session, error)
+	Extend(ctx context.Context, id string) error
+	FindByUserID(ctx context.Context, userID, currentID string) ([]Session, error)
+	Revoke(ctx context.Context, userID, id string) error
+	RevokeAll(ctx context.Context, userID string) (int64, error)
+}
+
+// maxUserAgent és la mida de la columna user_agent.
+const maxUserAgent = 500
+
+type sessionService struct {
+	repo SessionRepository
+	ttl  time.Duration
+}
+
+// NewSessionService rep la durada màxima d'una sessió sense refrescar el token
+// (MaxRefresh del middleware JWT).
+func NewSessionService(repo SessionRepository, ttl time.Duration) SessionService {
+	return &sessionService{repo: repo, ttl: ttl}
+}
+
+func (s *sessionService) Start(ctx context.Context, userID uuid.UUID, userAgent, ip string) (Session, error) {
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+	return s.repo.Create(ctx, Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+}
+
+// Extend allarga la sessió en refrescar el token.
+func (s *sessionService) Extend(ctx context.Context, id string) error {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrSessionExpired
+	}
+	err = s.repo.Extend(ctx, sessionID, time.Now().Add(s.ttl))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionExpired
+	}
+	return err
+}
+
+// FindByUserID retorna les sessions actives de l'usuari i marca la de la petició actual.
+func (s *sessionService) FindByUserID(ctx context.Context, userID, currentID string) ([]Session, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	sessions, err := s.repo.FindActiveByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentID
+	}
+	return sessions, nil
+}
+
+// Revoke tanca una sessió de l'usuari. Les sessions d'altres usuaris es tracten com si no existissin.
+func (s *sessionService) Revoke(ctx context.Context, userID, id string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	err = s.repo.Revoke(ctx, sessionID, userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+// RevokeAll tanca totes les sessions de l'usuari i retorna quantes n'ha tancat.
+func (s *sessionService) RevokeAll(ctx context.Context, userID string) (int64, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, ErrInvalidID
+	}
+	return s.repo.RevokeByUserID(ctx, userUUID)
+}
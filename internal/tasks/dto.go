@@ -6,7 +6,8 @@ type TaskRequest struct {
 	UserID      string       `json:"user_id" binding:"required"`
 	Status      TaskStatus   `json:"status" binding:"required,oneof=Pending ToDo InProgress Done"`
 	Priority    TaskPriority `json:"priority" binding:"required,oneof=A B C D"`
-	ProjectID   string       `json:"project_id" binding:"required"`
-	StartDate   *string      `json:"start_date,omitempty"`
-	EndDate     *string      `json:"end_date,omitempty"`
+	// ProjectID és opcional: les tasques que surten d'un acord poden no tenir projecte
+	ProjectID string  `json:"project_id"`
+	StartDate *string `json:"start_date,omitempty"`
+	EndDate   *string `json:"end_date,omitempty"`
 }
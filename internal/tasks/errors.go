@@ -9,4 +9,5 @@ var (
 	ErrInvalidPriority = errors.New("invalid task priority")
 	ErrInvalidDate    = errors.New("invalid date format, expected YYYY-MM-DD")
 	ErrTaskNotFound   = errors.New("task not found")
+	ErrAgreementHasTask = errors.New("agreement already has a task")
 )
\ No newline at end of file
@@ -0,0 +1,49 @@
+package tasks
+
+import (
+	"orkestra-api/internal/ical"
+	"time"
+)
+
+// Span retorna els dies que ocupa la tasca. Sense data d'inici compta la data de fi (la
+// data límit dels acords), i sense cap data la tasca no té lloc al calendari. Les tasques
+// antigues sense data tenen la data zero en lloc de NULL.
+func (t Task) Span() (time.Time, time.Time, bool) {
+	var start, end time.Time
+	if t.StartDate != nil && !t.StartDate.IsZero() {
+		start = t.StartDate.Local()
+	}
+	if t.EndDate != nil && !t.EndDate.IsZero() {
+		end = t.EndDate.Local()
+	}
+	switch {
+	case start.IsZero() && end.IsZero():
+		return time.Time{}, time.Time{}, false
+	case start.IsZero():
+		start = end
+	case end.IsZero():
+		end = start
+	}
+	return start, end, true
+}
+
+// CalendarEvents converteix les tasques amb data en esdeveniments de dia sencer.
+func CalendarEvents(tasks []Task) []ical.Event {
+	events := make([]ical.Event, 0, len(tasks))
+	for _, task := range tasks {
+		start, end, ok := task.Span()
+		if !ok {
+			continue
+		}
+		events = append(events, ical.Event{
+			UID:         "task-" + task.ID.String() + "@orkestra",
+			Summary:     task.Description,
+			Description: task.Notes,
+			Categories:  []string{"Tasques", string(task.Status)},
+			Start:       start,
+			End:         end,
+			AllDay:      true,
+		})
+	}
+	return events
+}
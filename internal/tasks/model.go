@@ -13,7 +13,9 @@ type Task struct {
 	UserID      uuid.UUID    `json:"user_id" db:"user_id"`
 	Status      TaskStatus   `json:"status" db:"status"`
 	Priority    TaskPriority `json:"priority" db:"priority"`
-	ProjectID   uuid.UUID    `json:"project_id" db:"project_id"`
+	ProjectID   *uuid.UUID   `json:"project_id" db:"project_id"`
 	StartDate *time.Time `json:"start_date,omitempty" db:"start_date"`
  	EndDate   *time.Time `json:"end_date,omitempty" db:"end_date"`
+	// AgreementID és l'acord de reunió d'on surt la tasca, si n'hi ha
+	AgreementID *uuid.UUID `json:"agreement_id,omitempty" db:"agreement_id"`
 }
\ No newline at end of file
@@ -17,6 +17,7 @@ type TaskRepository interface {
 	FindByProjectID(ctx context.Context, projectID uuid.UUID) ([]Task, error)
 	FindByPriority(ctx context.Context, priority TaskPriority) ([]Task, error)
 	FindAll(ctx context.Context) ([]Task, error)
+	FindByAgreementID(ctx context.Context, agreementID uuid.UUID) (Task, error)
 }
 
 type taskRepository struct {
@@ -31,9 +32,9 @@ func NewTaskRepository(db *sql.DB) TaskRepository{
 
 func (r *taskRepository) Create(ctx context.Context, task Task) (Task, error) {
 	_, err := r.db.ExecContext(ctx,`
-	INSERT INTO tasks(id, description, notes, user_id, status, priority, project_id, start_date, end_date)
-	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`, task.ID, task.Description, task.Notes, task.UserID, task.Status, task.Priority, task.ProjectID, task.StartDate, task.EndDate)
+	INSERT INTO tasks(id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, task.ID, task.Description, task.Notes, task.UserID, task.Status, task.Priority, task.ProjectID, task.StartDate, task.EndDate, task.AgreementID)
 	if err != nil {
 		return Task{}, err
 	}
@@ -64,10 +65,10 @@ func (r *taskRepository) Delete(ctx context.Context, id uuid.UUID) error{
 func (r *taskRepository) FindById(ctx context.Context, id uuid.UUID) (Task, error){
 	var task Task
 	err := r.db.QueryRowContext(ctx,`
-	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date
+	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id
 	FROM tasks WHERE id = $1
 	`, id,
-	).Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate)
+	).Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate, &task.AgreementID)
 	if err != nil {
 		return Task{}, err
 	}
@@ -77,7 +78,7 @@ func (r *taskRepository) FindById(ctx context.Context, id uuid.UUID) (Task, erro
 func (r *taskRepository) FindByStatus(ctx context.Context, status TaskStatus) ([]Task, error){
 	var tasks []Task
 	rows, err := r.db.QueryContext(ctx,`
-	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date
+	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id
 	FROM tasks WHERE status = $1
 	`, status,
 	)
@@ -87,7 +88,7 @@ func (r *taskRepository) FindByStatus(ctx context.Context, status TaskStatus) ([
 	defer rows.Close()
 	for rows.Next(){
 		var task Task
-		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate); err != nil {
+		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate, &task.AgreementID); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -100,7 +101,7 @@ func (r *taskRepository) FindByStatus(ctx context.Context, status TaskStatus) ([
 func (r *taskRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]Task, error){
 	var tasks []Task
 	rows, err := r.db.QueryContext(ctx,`
-	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date
+	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id
 	FROM tasks WHERE user_id = $1
 	`, userID,
 )
@@ -110,7 +111,7 @@ func (r *taskRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]
 	defer rows.Close()
 	for rows.Next(){
 		var task Task
-		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate); err != nil {
+		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate, &task.AgreementID); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -123,7 +124,7 @@ func (r *taskRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]
 func (r *taskRepository) FindByProjectID(ctx context.Context, projectID uuid.UUID) ([]Task, error){
 	var tasks []Task
 	rows, err := r.db.QueryContext(ctx,`
-	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date
+	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id
 	FROM tasks WHERE project_id = $1
 	`, projectID,
 )
@@ -133,7 +134,7 @@ func (r *taskRepository) FindByProjectID(ctx context.Context, projectID uuid.UUI
 	defer rows.Close()
 	for rows.Next(){
 		var task Task
-		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate); err != nil {
+		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate, &task.AgreementID); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -146,7 +147,7 @@ func (r *taskRepository) FindByProjectID(ctx context.Context, projectID uuid.UUI
 func (r *taskRepository) FindByPriority(ctx context.Context, priority TaskPriority) ([]Task, error){
 	var tasks []Task
 	rows, err := r.db.QueryContext(ctx,`
-	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date
+	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id
 	FROM tasks WHERE priority = $1
 	`, priority,
 )
@@ -156,7 +157,7 @@ func (r *taskRepository) FindByPriority(ctx context.Context, priority TaskPriori
 	defer rows.Close()
 	for rows.Next(){
 		var task Task
-		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate); err != nil {
+		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate, &task.AgreementID); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -169,7 +170,7 @@ func (r *taskRepository) FindByPriority(ctx context.Context, priority TaskPriori
 func (r *taskRepository) FindAll(ctx context.Context) ([]Task, error){
 	var tasks []Task
 	rows, err := r.db.QueryContext(ctx,`
-	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date
+	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id
 	FROM tasks ORDER BY project_id, id
 	`)
 	if err != nil {
@@ -178,7 +179,7 @@ func (r *taskRepository) FindAll(ctx context.Context) ([]Task, error){
 	defer rows.Close()
 	for rows.Next(){
 		var task Task
-		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate); err != nil {
+		if err := rows.Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate, &task.AgreementID); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -187,4 +188,16 @@ func (r *taskRepository) FindAll(ctx context.Context) ([]Task, error){
 		return nil, err
 	}
 	return tasks, nil
-}
\ No newline at end of file
+}
+func (r *taskRepository) FindByAgreementID(ctx context.Context, agreementID uuid.UUID) (Task, error){
+	var task Task
+	err := r.db.QueryRowContext(ctx,`
+	SELECT id, description, notes, user_id, status, priority, project_id, start_date, end_date, agreement_id
+	FROM tasks WHERE agreement_id = $1
+	`, agreementID,
+	).Scan(&task.ID, &task.Description, &task.Notes, &task.UserID, &task.Status, &task.Priority, &task.ProjectID, &task.StartDate, &task.EndDate, &task.AgreementID)
+	if err != nil {
+		return Task{}, err
+	}
+	return task, nil
+}
@@ -1,15 +1,19 @@
 package tasks
 
-import "github.com/gin-gonic/gin"
+import (
+	"orkestra-api/middleware"
 
-func RegisterRoutes(router *gin.RouterGroup, handler *TaskHandler){
-	router.POST("/tasks", handler.CreateTask)
-	router.PUT("/tasks/:id", handler.UpdateTask)
-	router.DELETE("/tasks/:id", handler.DeleteTask)
-	router.GET("/tasks/:id", handler.GetTaskByID)
-	router.GET("/tasks/status/:status", handler.GetTaskByStatus)
-	router.GET("/tasks/user/:userid", handler.GetTaskByUserID)
-	router.GET("/tasks/project/:projectid", handler.GetTaskByProjectID)
-	router.GET("/tasks/priority/:priority", handler.GetTaskByPriority)
-	router.GET("/tasks", handler.GetAllTask)
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *TaskHandler, authz *middleware.Authorizer){
+	router.POST("/tasks", authz.Require("tasks:write"), handler.CreateTask)
+	router.PUT("/tasks/:id", authz.Require("tasks:write"), handler.UpdateTask)
+	router.DELETE("/tasks/:id", authz.Require("tasks:write"), handler.DeleteTask)
+	router.GET("/tasks/:id", authz.Require("tasks:read"), handler.GetTaskByID)
+	router.GET("/tasks/status/:status", authz.Require("tasks:read"), handler.GetTaskByStatus)
+	router.GET("/tasks/user/:userid", authz.Require("tasks:read"), handler.GetTaskByUserID)
+	router.GET("/tasks/project/:projectid", authz.Require("tasks:read"), handler.GetTaskByProjectID)
+	router.GET("/tasks/priority/:priority", authz.Require("tasks:read"), handler.GetTaskByPriority)
+	router.GET("/tasks", authz.Require("tasks:read"), handler.GetAllTask)
 }
\ No newline at end of file
@@ -2,6 +2,8 @@ package tasks
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"orkestra-api/internal/projects"
 	"orkestra-api/internal/users"
 	"time"
@@ -19,6 +21,7 @@ type TaskService interface {
 	FindByProjectID(ctx context.Context, id string) ([]Task, error)
 	FindByPriority(ctx context.Context, priority string) ([]Task, error)
 	FindAll(ctx context.Context) ([]Task, error)
+	CreateForAgreement(ctx context.Context, agreementID uuid.UUID, request *TaskRequest) (Task, error)
 }
 
 type taskService struct {
@@ -42,6 +45,30 @@ func(s *taskService) Create(ctx context.Context, request *TaskRequest) (Task, er
 	}
 	return s.repo.Create(ctx, task)
 }
+// CreateForAgreement crea la tasca de seguiment d'un acord. Cada acord en pot tenir només una.
+func(s *taskService) CreateForAgreement(ctx context.Context, agreementID uuid.UUID, request *TaskRequest) (Task, error){
+	_, err := s.repo.FindByAgreementID(ctx, agreementID)
+	if err == nil {
+		return Task{}, ErrAgreementHasTask
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Task{}, err
+	}
+	task, err := createModelFromRequest(request)
+	if err != nil {
+		return Task{}, err
+	}
+	if _, err := s.users.FindByID(ctx, request.UserID); err != nil {
+		return Task{}, err
+	}
+	if task.ProjectID != nil {
+		if _, err := s.projects.FindById(ctx, request.ProjectID); err != nil {
+			return Task{}, err
+		}
+	}
+	task.AgreementID = &agreementID
+	return s.repo.Create(ctx, task)
+}
 func(s *taskService) Update(ctx context.Context, id string, request *TaskRequest) (Task, error){
 	task, err := createModelFromRequest(request)
 	if err != nil {
@@ -116,16 +143,20 @@ func(s *taskService) FindAll(ctx context.Context) ([]Task, error){
 }
 
 func createModelFromRequest(request *TaskRequest)(Task, error){
-if request.Description == "" || request.UserID == "" || request.Status == "" || request.Priority == "" || request.ProjectID == "" {
+if request.Description == "" || request.UserID == "" || request.Status == "" || request.Priority == "" {
 		return Task{}, ErrInvalidRequest
 	}
 	userID, err := uuid.Parse(request.UserID)
 	if err != nil {
 		return Task{}, ErrInvalidID
 	}
-	projectID, err := uuid.Parse(request.ProjectID)
-	if err != nil {
-		return Task{}, ErrInvalidID
+	var projectID *uuid.UUID
+	if request.ProjectID != "" {
+		id, err := uuid.Parse(request.ProjectID)
+		if err != nil {
+			return Task{}, ErrInvalidID
+		}
+		projectID = &id
 	}
 	if !IsValidStatus(request.Status){
 		return Task{}, ErrInvalidStatus
@@ -134,19 +165,22 @@ if request.Description == "" || request.UserID == "" || request.Status == "" ||
 		return Task{}, ErrInvalidPriority
 	}
 	layout := time.RFC3339 
-	var startDate time.Time
-	var endDate time.Time
+	// Sense data es desa NULL, no la data zero
+	var startDate *time.Time
+	var endDate *time.Time
 	if request.StartDate != nil && len(*request.StartDate) > 0 {
-		startDate, err = time.Parse(layout, *request.StartDate)
+		date, err := time.Parse(layout, *request.StartDate)
 		if err != nil {
 			return Task{}, ErrInvalidDate
 		}
+		startDate = &date
 	}
 	if request.EndDate != nil && len(*request.EndDate) > 0 {
-		endDate, err = time.Parse(layout, *request.EndDate)
+		date, err := time.Parse(layout, *request.EndDate)
 		if err != nil {
 			return Task{}, ErrInvalidDate
 		}
+		endDate = &date
 	}
 	
 
@@ -159,8 +193,8 @@ if request.Description == "" || request.UserID == "" || request.Status == "" ||
 		Status: TaskStatus(request.Status),
 		Priority: TaskPriority(request.Priority),
 		ProjectID: projectID,
-		StartDate: &startDate,
-		EndDate: &endDate,
+		StartDate: startDate,
+		EndDate: endDate,
 	}
 	return task, nil
 }
\ No newline at end of file
@@ -0,0 +1,5 @@
+package twofactor
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
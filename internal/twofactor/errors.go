@@ -0,0 +1,11 @@
+package twofactor
+
+import "errors"
+
+var (
+	ErrInvalidID       = errors.New("invalid user ID")
+	ErrInvalidCode     = errors.New("invalid two-factor code")
+	ErrAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrNotEnrolled     = errors.New("two-factor authentication is not enrolled")
+	ErrRequiredByAdmin = errors.New("two-factor authentication is required by the user profile")
+)
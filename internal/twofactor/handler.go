@@ -0,0 +1,95 @@
+package twofactor
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	service TwoFactorService
+}
+
+func NewTwoFactorHandler(service TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		service: service,
+	}
+}
+
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	status, err := h.service.Status(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	enrolment, err := h.service.Enroll(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, enrolment)
+}
+
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	var request CodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.Confirm(c.Request.Context(), userID.(string), request.Code); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication enabled"})
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+	var request CodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.Disable(c.Request.Context(), userID.(string), request.Code); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidCode):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotEnrolled):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyEnabled), errors.Is(err, ErrRequiredByAdmin):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
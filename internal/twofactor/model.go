@@ -0,0 +1,31 @@
+package twofactor
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTOTP és el secret TOTP d'un usuari. Mentre EnabledAt és nil l'alta està pendent
+// de confirmar amb un primer codi.
+type UserTOTP struct {
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Secret    string     `json:"-" db:"secret"`
+	LastStep  int64      `json:"-" db:"last_step"`
+	EnabledAt *time.Time `json:"enabled_at" db:"enabled_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Enrolment és el que rep l'usuari en donar d'alta el segon factor. Els codis de
+// recuperació només es mostren aquest cop.
+type Enrolment struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type Status struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RemainingRecoveryCodes int  `json:"remaining_recovery_codes"`
+}
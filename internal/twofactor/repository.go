@@ -0,0 +1,181 @@
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID uuid.UUID) (UserTOTP, error)
+	FindAccountName(ctx context.Context, userID uuid.UUID) (string, error)
+	IsRequired(ctx context.Context, userID uuid.UUID) (bool, error)
+	SavePending(ctx context.Context, userID uuid.UUID, secret string, recoveryCodeHashes []string) error
+	Enable(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+type twoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+func (r *twoFactorRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (UserTOTP, error) {
+	var totp UserTOTP
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id, secret, last_step, enabled_at, created_at
+		FROM user_totp
+		WHERE user_id = $1`,
+		userID,
+	).Scan(&totp.UserID, &totp.Secret, &totp.LastStep, &totp.EnabledAt, &totp.CreatedAt)
+	if err != nil {
+		return UserTOTP{}, err
+	}
+	return totp, nil
+}
+
+func (r *twoFactorRepository) FindAccountName(ctx context.Context, userID uuid.UUID) (string, error) {
+	var username string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT username
+		FROM users
+		WHERE id = $1`,
+		userID,
+	).Scan(&username)
+	return username, err
+}
+
+// IsRequired indica si el perfil de l'usuari obliga a tenir segon factor.
+func (r *twoFactorRepository) IsRequired(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var required bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(p.require_2fa, FALSE)
+		FROM users u
+			LEFT JOIN profiles p ON p.id = u.profile_id
+		WHERE u.id = $1`,
+		userID,
+	).Scan(&required)
+	return required, err
+}
+
+// SavePending desa un secret nou pendent de confirmar i substitueix els codis de recuperació.
+func (r *twoFactorRepository) SavePending(ctx context.Context, userID uuid.UUID, secret string, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret, last_step, enabled_at, created_at)
+		VALUES ($1, $2, 0, NULL, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_step = 0, enabled_at = NULL, created_at = NOW()`,
+		userID, secret,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM user_recovery_codes
+		WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES ($1, $2, $3, NOW())`,
+			uuid.New(), userID, codeHash,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *twoFactorRepository) Enable(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp
+		SET enabled_at = NOW(), last_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL`,
+		userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	return affected(result)
+}
+
+// UseStep registra el pas de temps del codi acceptat. Si ja s'ha fet servir aquest pas
+// o un de posterior retorna false, i així un codi no es pot reutilitzar.
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp
+		SET last_step = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_step < $2`,
+		userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	return affected(result)
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	return affected(result)
+}
+
+func (r *twoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM user_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *twoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func affected(result sql.Result) (bool, error) {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,19 @@
+package twofactor
+
+import (
+	"orkestra-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registra la gestió del segon factor de l'usuari que fa la petició.
+// No cal cap permís: cadascú gestiona el seu, però sí una sessió d'usuari.
+func RegisterRoutes(router *gin.RouterGroup, handler *TwoFactorHandler) {
+	twoFactor := router.Group("/me/2fa", middleware.RejectAPITokens)
+	{
+		twoFactor.GET("", handler.GetStatus)
+		twoFactor.POST("/enroll", handler.Enroll)
+		twoFactor.POST("/confirm", handler.Confirm)
+		twoFactor.DELETE("", handler.Disable)
+	}
+}
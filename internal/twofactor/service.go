@@ -0,0 +1,208 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"orkestra-api/internal/usertokens"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	issuer            = "Orkestra"
+	recoveryCodeCount = 10
+)
+
+type TwoFactorService interface {
+	Status(ctx context.Context, userID string) (Status, error)
+	Enroll(ctx context.Context, userID string) (Enrolment, error)
+	Confirm(ctx context.Context, userID, code string) error
+	Verify(ctx context.Context, userID, code string) error
+	Disable(ctx context.Context, userID, code string) error
+}
+
+type twoFactorService struct {
+	repo TwoFactorRepository
+}
+
+func NewTwoFactorService(repo TwoFactorRepository) TwoFactorService {
+	return &twoFactorService{repo: repo}
+}
+
+func (s *twoFactorService) Status(ctx context.Context, userID string) (Status, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return Status{}, ErrInvalidID
+	}
+	var status Status
+	status.Required, err = s.repo.IsRequired(ctx, userUUID)
+	if err != nil {
+		return Status{}, err
+	}
+	totp, err := s.repo.FindByUserID(ctx, userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, nil
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	status.Enabled = totp.EnabledAt != nil
+	if status.Enabled {
+		status.RemainingRecoveryCodes, err = s.repo.CountRecoveryCodes(ctx, userUUID)
+		if err != nil {
+			return Status{}, err
+		}
+	}
+	return status, nil
+}
+
+// Enroll genera un secret i codis de recuperació nous. El segon factor no s'activa fins
+// que l'usuari el confirma amb un codi (Confirm).
+func (s *twoFactorService) Enroll(ctx context.Context, userID string) (Enrolment, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return Enrolment{}, ErrInvalidID
+	}
+	existing, err := s.repo.FindByUserID(ctx, userUUID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Enrolment{}, err
+	}
+	if err == nil && existing.EnabledAt != nil {
+		return Enrolment{}, ErrAlreadyEnabled
+	}
+
+	account, err := s.repo.FindAccountName(ctx, userUUID)
+	if err != nil {
+		return Enrolment{}, err
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return Enrolment{}, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return Enrolment{}, err
+	}
+	if err := s.repo.SavePending(ctx, userUUID, secret, hashes); err != nil {
+		return Enrolment{}, err
+	}
+	return Enrolment{
+		Secret:        secret,
+		OTPAuthURI:    otpauthURI(issuer, account, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Confirm activa el segon factor pendent si el codi és correcte.
+func (s *twoFactorService) Confirm(ctx context.Context, userID, code string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	totp, err := s.repo.FindByUserID(ctx, userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if totp.EnabledAt != nil {
+		return ErrAlreadyEnabled
+	}
+	step := matchStep(totp.Secret, normaliseCode(code), time.Now())
+	if step < 0 {
+		return ErrInvalidCode
+	}
+	enabled, err := s.repo.Enable(ctx, userUUID, step)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrAlreadyEnabled
+	}
+	return nil
+}
+
+// Verify accepta un codi TOTP o un codi de recuperació, cadascun d'un sol ús.
+func (s *twoFactorService) Verify(ctx context.Context, userID, code string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	totp, err := s.repo.FindByUserID(ctx, userUUID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && totp.EnabledAt == nil) {
+		return ErrNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+
+	code = normaliseCode(code)
+	if step := matchStep(totp.Secret, code, time.Now()); step >= 0 {
+		used, err := s.repo.UseStep(ctx, userUUID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userUUID, usertokens.HashToken(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// Disable treu el segon factor després de comprovar un codi, si el perfil no l'exigeix.
+func (s *twoFactorService) Disable(ctx context.Context, userID, code string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	required, err := s.repo.IsRequired(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrRequiredByAdmin
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userUUID)
+}
+
+// normaliseCode treu espais i guions perquè els codis de recuperació es puguin escriure
+// com es mostren (xxxxx-xxxxx) o tot junt.
+func normaliseCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(raw))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, usertokens.HashToken(code))
+	}
+	return codes, hashes, nil
+}
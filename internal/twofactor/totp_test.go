@@ -0,0 +1,83 @@
+package twofactor
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret de l'RFC 6238 ("12345678901234567890") en base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// Vectors SHA1 de l'apèndix B de l'RFC 6238, amb els sis últims dígits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rfcSecret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatalf("totpCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchStep(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+	code := func(step int64) string {
+		c, err := totpCode(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name string
+		code string
+		want int64
+	}{
+		{name: "current step", code: code(current), want: current},
+		{name: "previous step", code: code(current - 1), want: current - 1},
+		{name: "next step", code: code(current + 1), want: current + 1},
+		{name: "outside skew", code: code(current - 2), want: -1},
+		{name: "wrong code", code: "000000", want: -1},
+		{name: "too short", code: code(current)[:5], want: -1},
+		{name: "recovery code", code: "abcde-fghij", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchStep(rfcSecret, tt.code, now); got != tt.want {
+				t.Errorf("matchStep(%q) = %d, want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+
+	if got := matchStep("not base32!", "123456", now); got != -1 {
+		t.Errorf("matchStep with an invalid secret = %d, want -1", got)
+	}
+}
+
+func TestNormaliseCode(t *testing.T) {
+	tests := map[string]string{
+		" 123 456 ":   "123456",
+		"ABCDE-FGHIJ": "abcdefghij",
+		"abcdefghij":  "abcdefghij",
+	}
+	for in, want := range tests {
+		if got := normaliseCode(in); got != want {
+			t.Errorf("normaliseCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
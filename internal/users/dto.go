@@ -12,7 +12,8 @@ type CreateUserRequest struct {
 	Email       string `json:"email" binding:"required"`
 	Username    string `json:"username" binding:"required"`
 	Password    string `json:"password" binding:"required"`
-	ProfileID   string `json:"profile_id" binding:"required"`
+	// Al registre públic s'ignora: el perfil és REGISTRATION_PROFILE_ID
+	ProfileID   string `json:"profile_id"`
 }
 
 type UpdateUserRequest struct {
@@ -25,16 +26,40 @@ type UpdateUserRequest struct {
 	ProfileID   string `json:"profile_id" binding:"required"`
 }
 
+// UpdateMeRequest són les dades que l'usuari pot canviar del seu propi compte.
+type UpdateMeRequest struct {
+	Name        string `json:"name"`
+	Surname     string `json:"surname"`
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Email       string `json:"email" binding:"required,email"`
+}
+
+// ChangePasswordRequest canvia la contrasenya de l'usuari que fa la petició.
 type ChangePasswordRequest struct {
-	ID       string `json:"id" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	OldPassword string `json:"old_password" binding:"required"`
+	Password    string `json:"password" binding:"required,min=8"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// VerifyUserRequest porta el token rebut per correu. L'ID és opcional: si hi és,
+// ha de coincidir amb l'usuari del token.
 type VerifyUserRequest struct {
-	ID               string `json:"id" binding:"required"`
+	ID               string `json:"id"`
 	ValidationString string `json:"validation_string" binding:"required"`
 }
 
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 type UserResponse struct {
 	ID                string  `json:"id" db:"id"`
 	Name              string  `json:"name" db:"name"`
@@ -42,7 +67,6 @@ type UserResponse struct {
 	PhoneNumber       string  `json:"phone_number" db:"phone_number"`
 	Email             string  `json:"email" db:"email"`
 	Username          string  `json:"username" db:"username"`
-	Password          string  `json:"password" db:"password"`
 	IsVerified        bool    `json:"is_verified" db:"is_verified"`
 	IsActive          bool    `json:"is_active" db:"is_active"`
 	CreatedAt         string  `json:"created_at" db:"created_at"`
@@ -7,6 +7,12 @@ var (
 	ErrInvalidID      = errors.New("invalid user ID")
 	ErrPhoneNumberTaken  = errors.New("phone number already taken")
 	ErrUsernameTaken  = errors.New("username already taken")
+	ErrEmailTaken     = errors.New("email already taken")
 	ErrInvalidRequest = errors.New("invalid request")
 	ErrInactiveUser   = errors.New("inactive user")
+	ErrInvalidValidationString = errors.New("invalid or expired validation string")
+	ErrInvalidPassword = errors.New("current password is not correct")
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrRegistrationClosed = errors.New("registration is by invitation only")
+	ErrAdminProfile = errors.New("assigning or removing an admin profile requires profiles:admin")
 )
\ No newline at end of file
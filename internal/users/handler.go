@@ -1,7 +1,9 @@
 package users
 
 import (
+	"errors"
 	"net/http"
+	"orkestra-api/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,16 +16,26 @@ func NewUserHandler(userService UserService) *UserHandler {
 	return &UserHandler{userService: userService}
 }
 
-func (h *UserHandler) Create(c *gin.Context) {	
+// Register és el registre públic, que només està obert amb OPEN_REGISTRATION
+func (h *UserHandler) Register(c *gin.Context) {	
 	var request CreateUserRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.userService.Create(c.Request.Context(), request)
+	user, err := h.userService.Register(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrRegistrationClosed):
+			status = http.StatusForbidden
+		case errors.Is(err, ErrInvalidRequest):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrUsernameTaken), errors.Is(err, ErrPhoneNumberTaken):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -38,9 +50,20 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Update(c.Request.Context(), id, request)
+	// Com a les invitacions, els perfils d'administrador només els assigna qui té profiles:admin
+	canGrantAdmin := middleware.HasPermission(c, "profiles:admin")
+	user, err := h.userService.Update(c.Request.Context(), id, canGrantAdmin, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidID):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrAdminProfile), errors.Is(err, ErrInactiveUser):
+			status = http.StatusForbidden
+		case errors.Is(err, ErrUserNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -65,15 +88,62 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.ChangePassword(c.Request.Context(), request)
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No s'ha trobat l'identitat de l'usuari"})
+		return
+	}
+
+	user, err := h.userService.ChangePassword(c.Request.Context(), userID.(string), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidPassword):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrInactiveUser):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var request ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.ForgotPassword(c.Request.Context(), request); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email belongs to an account, a reset link has been sent"})
+}
+
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var request ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), request); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidResetToken) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+}
+
 func (h *UserHandler) VerifyUser(c *gin.Context) {
 	var request VerifyUserRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -83,13 +153,36 @@ func (h *UserHandler) VerifyUser(c *gin.Context) {
 
 	user , err := h.userService.VerifyUser(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidValidationString):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrInactiveUser):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "user verified successfully", "user": user})
 }
 
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	var request ResendVerificationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.ResendVerification(c.Request.Context(), request); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Sempre la mateixa resposta, existeixi o no el correu
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email belongs to an unverified account, a new verification link has been sent"})
+}
+
 func(h *UserHandler) GetByUsername(c *gin.Context) {
 	username := c.Param("username")
 	user, err := h.userService.FindByUsername(c.Request.Context(), username)
@@ -13,7 +13,7 @@ type User struct {
 	PhoneNumber string    `json:"phone_number" db:"phone_number"`
 	Email		   string `json:"email" db:"email"`
 	Username string    `json:"username" db:"username"`
-	Password string    `json:"password" db:"password"`
+	Password string    `json:"-" db:"password"`
 	IsVerified bool `json:"is_verified" db:"is_verified"`
 	IsActive bool `json:"is_active" db:"is_active"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
@@ -11,14 +11,17 @@ import (
 type UserRepository interface {	
 	Create(ctx context.Context, user User) (User, error)
 	Update(ctx context.Context, user User) (User, error)
+	UpdateContact(ctx context.Context, user User) error
 	Delete(ctx context.Context, id uuid.UUID) (error)
-	ChangePassword(ctx context.Context, request ChangePasswordRequest) (User, error)
+	ChangePassword(ctx context.Context, id uuid.UUID, hashedPassword string) (User, error)
 	VerifyUser(ctx context.Context, id uuid.UUID) (error)
 	FindByID(ctx context.Context, id uuid.UUID) (User, error)
 	FindByUsername(ctx context.Context, username string) (User, error)	
 	FindByPhoneNumber(ctx context.Context, phoneNumber string) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
 	FindAll(ctx context.Context) ([]User, error)
 	FindByGroupId(ctx context.Context, groupId uuid.UUID) ([]User,error)
+	IsAdminProfile(ctx context.Context, profileID uuid.UUID) (bool, error)
 }
 
 type userRepository struct {
@@ -54,6 +57,20 @@ func(r *userRepository) Update(ctx context.Context, user User) (User, error) {
 	return user, nil
 }
 
+// UpdateContact desa les dades que l'usuari pot editar ell mateix i l'estat de verificació
+func(r *userRepository) UpdateContact(ctx context.Context, user User) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET name = $1, surname = $2, phone_number = $3, email = $4, is_verified = $5
+		WHERE id = $6`,
+		user.Name, user.Surname, user.PhoneNumber, user.Email, user.IsVerified, user.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating user: %w", err)
+	}
+	return nil
+}
+
 func(r *userRepository) Delete(ctx context.Context, id uuid.UUID) (error) {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE users
@@ -66,20 +83,16 @@ func(r *userRepository) Delete(ctx context.Context, id uuid.UUID) (error) {
 	return nil
 }
 
-func(r *userRepository) ChangePassword(ctx context.Context, request ChangePasswordRequest) (User, error){
+func(r *userRepository) ChangePassword(ctx context.Context, id uuid.UUID, hashedPassword string) (User, error){
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE users
 		SET password = $1, password_changed_at = now()
 		WHERE id = $2`,
-		request.Password, request.ID)
+		hashedPassword, id)
 	if err != nil {
 		return User{}, fmt.Errorf("error changing password: %w", err)
 	}
-	strId, err  := uuid.Parse(request.ID)
-	if err != nil {
-		return User{}, fmt.Errorf("error parsing id: %w", err)
-	}
-	user, err := r.FindByID(ctx, strId)
+	user, err := r.FindByID(ctx, id)
 	if err != nil {
 		return User{}, err
 	}
@@ -146,6 +159,22 @@ func(r *userRepository) FindByPhoneNumber(ctx context.Context, phoneNumber strin
 	return user, nil
 }
 
+func(r *userRepository) FindByEmail(ctx context.Context, email string) (User, error)	{
+	var user User
+	row := r.db.QueryRowContext(ctx, `SELECT id, name, surname, phone_number, email, username, password, is_verified, is_active, created_at, password_changed_at, profile_id FROM users WHERE lower(email) = lower($1)`, email)
+	
+	err := row.Scan(&user.ID, &user.Name, &user.Surname, &user.PhoneNumber, &user.Email, &user.Username, &user.Password, &user.IsVerified, &user.IsActive, &user.CreatedAt, &user.PasswordChangedAt, &user.ProfileID)
+	if err == sql.ErrNoRows {
+		return User{}, ErrUserNotFound
+	}else if err != nil {
+		return User{}, fmt.Errorf("error getting user: %w", err)
+	}
+	if !user.IsActive {
+		return User{}, ErrInactiveUser
+	}
+	return user, nil
+}
+
 func(r *userRepository) FindAll(ctx context.Context) ([]User, error){
 	var users []User
 	rows, err := r.db.QueryContext(ctx, `SELECT id, name, surname, phone_number, email, username, password, is_verified, is_active, created_at, password_changed_at, profile_id FROM users`)
@@ -184,4 +213,11 @@ func(r *userRepository) FindByGroupId(ctx context.Context, groupId uuid.UUID) ([
 		users = append(users, user)
 	}
 	return users, nil
-}
\ No newline at end of file
+}
+
+// IsAdminProfile indica si el perfil és d'administrador. Retorna sql.ErrNoRows si no existeix.
+func (r *userRepository) IsAdminProfile(ctx context.Context, profileID uuid.UUID) (bool, error) {
+	var isAdmin bool
+	err := r.db.QueryRowContext(ctx, `SELECT is_admin FROM profiles WHERE id = $1`, profileID).Scan(&isAdmin)
+	return isAdmin, err
+}
@@ -1,22 +1,30 @@
 package users
 
-import "github.com/gin-gonic/gin"
+import (
+	"orkestra-api/middleware"
 
-func RegisterRoutes(router *gin.RouterGroup, handler *UserHandler) {
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, handler *UserHandler, authz *middleware.Authorizer) {
 	roles := router.Group("/users")
 	{		
-		roles.PUT("/:id", handler.Update)
-		roles.DELETE("/:id", handler.Delete)
-		roles.POST("/change-password", handler.ChangePassword)				
-		roles.GET("/username/:username", handler.GetByUsername)
-		roles.GET("/phone/:phone_number", handler.GetByPhoneNumber)
-		roles.GET("/:id", handler.GetByID)
-		roles.GET("", handler.GetAll)
-		roles.GET("/group/:group_id", handler.GetByGroupID)
+		roles.PUT("/:id", authz.Require("users:write"), handler.Update)
+		roles.DELETE("/:id", authz.Require("users:write"), handler.Delete)
+		// Cadascú canvia només la seva contrasenya, no cal cap permís però sí una sessió d'usuari
+		roles.POST("/change-password", middleware.RejectAPITokens, handler.ChangePassword)				
+		roles.GET("/username/:username", authz.Require("users:read"), handler.GetByUsername)
+		roles.GET("/phone/:phone_number", authz.Require("users:read"), handler.GetByPhoneNumber)
+		roles.GET("/:id", authz.Require("users:read"), handler.GetByID)
+		roles.GET("", authz.Require("users:read"), handler.GetAll)
+		roles.GET("/group/:group_id", authz.Require("users:read"), handler.GetByGroupID)
 	}
 }
 
 func RegisterPublicRoutes(router *gin.RouterGroup, handler *UserHandler) {
-    router.POST("/register", handler.Create) // Ruta pública per crear usuaris
+    router.POST("/register", handler.Register) // Registre públic, si OPEN_REGISTRATION ho permet
 	router.POST("/verify", handler.VerifyUser)
+	router.POST("/verify/resend", handler.ResendVerification)
+	router.POST("/password/forgot", handler.ForgotPassword)
+	router.POST("/password/reset", handler.ResetPassword)
 }
\ No newline at end of file
@@ -5,6 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
+	"net/url"
+	"orkestra-api/config"
+	"orkestra-api/internal/mailer"
+	"orkestra-api/internal/usertokens"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,10 +19,15 @@ import (
 
 type UserService interface {
 	Create(ctx context.Context, request CreateUserRequest) (UserResponse, error)
-	Update(ctx context.Context, id string, request UpdateUserRequest)(UserResponse, error)
+	Register(ctx context.Context, request CreateUserRequest) (UserResponse, error)
+	Update(ctx context.Context, id string, canGrantAdmin bool, request UpdateUserRequest)(UserResponse, error)
 	Delete(ctx context.Context, id string) (error)
-	ChangePassword(ctx context.Context, request ChangePasswordRequest) (UserResponse, error)
+	UpdateMe(ctx context.Context, id string, request UpdateMeRequest) (UserResponse, error)
+	ChangePassword(ctx context.Context, id string, request ChangePasswordRequest) (UserResponse, error)
+	ForgotPassword(ctx context.Context, request ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, request ResetPasswordRequest) error
 	VerifyUser(ctx context.Context, request VerifyUserRequest) (UserResponse, error)
+	ResendVerification(ctx context.Context, request ResendVerificationRequest) error
 	FindByUsername(ctx context.Context, username string) (UserResponse, error)
 	FindByID(ctx context.Context, id string) (UserResponse, error)
 	FindByPhoneNumber(ctx context.Context, phoneNumber string) (UserResponse, error)
@@ -26,14 +37,27 @@ type UserService interface {
 
 type userService struct {
 	repo UserRepository
+	tokenService usertokens.TokenService
+	mailer mailer.Mailer
+	cfg config.Config
 }
 
-func NewUserService(repo UserRepository) UserService {
-	return &userService{repo}
+func NewUserService(repo UserRepository, tokenService usertokens.TokenService, mailer mailer.Mailer, cfg config.Config) UserService {
+	return &userService{
+		repo: repo,
+		tokenService: tokenService,
+		mailer: mailer,
+		cfg: cfg,
+	}
 }
 
-func mapUserToResponse(user User) UserResponse {
-	passwordchangedat := user.PasswordChangedAt.Format(time.RFC3339)
+// MapUserToResponse converteix l'usuari a la resposta de l'API, sense el hash de la contrasenya
+func MapUserToResponse(user User) UserResponse {
+	var passwordchangedat *string
+	if user.PasswordChangedAt != nil {
+		formatted := user.PasswordChangedAt.Format(time.RFC3339)
+		passwordchangedat = &formatted
+	}
 	response := UserResponse{
 		ID:       user.ID.String(),
 		Name:     user.Name,
@@ -44,7 +68,7 @@ func mapUserToResponse(user User) UserResponse {
 		IsVerified: user.IsVerified,
 		IsActive: user.IsActive,
 		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		PasswordChangedAt: &passwordchangedat,		
+		PasswordChangedAt: passwordchangedat,		
 		ProfileID: user.ProfileID.String(),		
 	}
 	return response
@@ -56,9 +80,13 @@ func(s *userService) Create(ctx context.Context, request CreateUserRequest) (Use
 		request.PhoneNumber == "" || request.Email == "" {
 		return UserResponse{} , ErrInvalidRequest
 	}
+	profileID, err := uuid.Parse(request.ProfileID)
+	if err != nil {
+		return UserResponse{}, ErrInvalidRequest
+	}
 
 	// Check if the username is already taken
-	_, err := s.repo.FindByUsername(ctx, request.Username)	
+	_, err = s.repo.FindByUsername(ctx, request.Username)	
 	if err == nil {		
 		return UserResponse{}, ErrUsernameTaken
 	}
@@ -88,7 +116,7 @@ func(s *userService) Create(ctx context.Context, request CreateUserRequest) (Use
 		IsActive: true,		
 		CreatedAt: now,
 		PasswordChangedAt: &now,
-		ProfileID: uuid.MustParse(request.ProfileID),
+		ProfileID: profileID,
 	}
 
 	// Insert the user into the database
@@ -97,17 +125,40 @@ func(s *userService) Create(ctx context.Context, request CreateUserRequest) (Use
 		return UserResponse{}, err
 	}
 
-	//Create a validation string store in redis and send it to the user via email or sms
+	// Si el correu falla l'usuari es crea igualment i pot demanar-ne un altre
+	if err := s.sendVerification(ctx, createdUser); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", createdUser.ID, err)
+	}
 
-	return mapUserToResponse(createdUser), nil
+	return MapUserToResponse(createdUser), nil
 }
 
-func(s *userService) Update(ctx context.Context,id string,  request UpdateUserRequest)(UserResponse, error){
+// Register crea un usuari des del registre públic. Només funciona amb OPEN_REGISTRATION i el
+// perfil sempre és REGISTRATION_PROFILE_ID: qui es registra no pot triar-lo. Si no, cal una invitació.
+func (s *userService) Register(ctx context.Context, request CreateUserRequest) (UserResponse, error) {
+	if !s.cfg.OpenRegistration || s.cfg.RegistrationProfileID == "" {
+		return UserResponse{}, ErrRegistrationClosed
+	}
+	request.ProfileID = s.cfg.RegistrationProfileID
+	return s.Create(ctx, request)
+}
+
+// Update modifica un usuari. Només qui té profiles:admin pot donar un perfil d'administrador
+// o treure'l a qui en té un.
+func(s *userService) Update(ctx context.Context,id string, canGrantAdmin bool, request UpdateUserRequest)(UserResponse, error){
 	if id == "" || request.Username == "" || request.PhoneNumber == "" {
 		return UserResponse{} , ErrInvalidRequest
 	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return UserResponse{}, ErrInvalidID
+	}
+	profileID, err := uuid.Parse(request.ProfileID)
+	if err != nil {
+		return UserResponse{}, ErrInvalidRequest
+	}
 
-	existingUser, err := s.repo.FindByID(ctx, uuid.MustParse(id))
+	existingUser, err := s.repo.FindByID(ctx, userID)
 	if err != nil && !errors.Is(err, ErrUserNotFound){
 		return UserResponse{}, fmt.Errorf("something went wrong getting the user")
 	}
@@ -117,9 +168,26 @@ func(s *userService) Update(ctx context.Context,id string,  request UpdateUserRe
 	if !existingUser.IsActive {
 		return UserResponse{}, ErrInactiveUser
 	}
+	if profileID != existingUser.ProfileID && !canGrantAdmin {
+		for _, checkID := range []uuid.UUID{profileID, existingUser.ProfileID} {
+			if checkID == uuid.Nil {
+				continue
+			}
+			isAdmin, err := s.repo.IsAdminProfile(ctx, checkID)
+			if err == sql.ErrNoRows {
+				return UserResponse{}, ErrInvalidRequest
+			}
+			if err != nil {
+				return UserResponse{}, err
+			}
+			if isAdmin {
+				return UserResponse{}, ErrAdminProfile
+			}
+		}
+	}
 	now := time.Now()
 	user := User{
-		ID:       uuid.MustParse(id),
+		ID:       userID,
 		Name:     request.Name,
 		Surname:  request.Surname,
 		PhoneNumber: request.PhoneNumber,
@@ -127,7 +195,7 @@ func(s *userService) Update(ctx context.Context,id string,  request UpdateUserRe
 		Username: request.Username,		
 		IsActive: request.IsActive,		
 		PasswordChangedAt: &now,
-		ProfileID: uuid.MustParse(request.ProfileID),
+		ProfileID: profileID,
 	}
 
 	response, err := s.repo.Update(ctx, user)
@@ -136,7 +204,66 @@ func(s *userService) Update(ctx context.Context,id string,  request UpdateUserRe
 	}
 	response.IsVerified = existingUser.IsVerified
 	response.CreatedAt = existingUser.CreatedAt
-	return mapUserToResponse(response), nil
+	return MapUserToResponse(response), nil
+}
+
+// UpdateMe actualitza les dades de contacte de l'usuari que fa la petició. Si canvia el
+// correu, cal tornar-lo a verificar.
+func (s *userService) UpdateMe(ctx context.Context, id string, request UpdateMeRequest) (UserResponse, error) {
+	if request.PhoneNumber == "" || request.Email == "" {
+		return UserResponse{}, ErrInvalidRequest
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return UserResponse{}, ErrInvalidID
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	if !user.IsActive {
+		return UserResponse{}, ErrInactiveUser
+	}
+
+	// Un usuari desactivat també ocupa el telèfon i el correu
+	if request.PhoneNumber != user.PhoneNumber {
+		other, err := s.repo.FindByPhoneNumber(ctx, request.PhoneNumber)
+		if (err == nil && other.ID != user.ID) || errors.Is(err, ErrInactiveUser) {
+			return UserResponse{}, ErrPhoneNumberTaken
+		}
+		if err != nil && !errors.Is(err, ErrUserNotFound) && !errors.Is(err, ErrInactiveUser) {
+			return UserResponse{}, err
+		}
+	}
+	emailChanged := !strings.EqualFold(request.Email, user.Email)
+	if emailChanged {
+		other, err := s.repo.FindByEmail(ctx, request.Email)
+		if (err == nil && other.ID != user.ID) || errors.Is(err, ErrInactiveUser) {
+			return UserResponse{}, ErrEmailTaken
+		}
+		if err != nil && !errors.Is(err, ErrUserNotFound) && !errors.Is(err, ErrInactiveUser) {
+			return UserResponse{}, err
+		}
+	}
+
+	user.Name = request.Name
+	user.Surname = request.Surname
+	user.PhoneNumber = request.PhoneNumber
+	user.Email = request.Email
+	if emailChanged {
+		user.IsVerified = false
+	}
+	if err := s.repo.UpdateContact(ctx, user); err != nil {
+		return UserResponse{}, err
+	}
+
+	if emailChanged {
+		if err := s.sendVerification(ctx, user); err != nil {
+			log.Printf("Error sending verification email to user %s: %v", user.ID, err)
+		}
+	}
+	return MapUserToResponse(user), nil
 }
 
 func (s *userService) Delete(ctx context.Context, id string) error {
@@ -163,44 +290,111 @@ func (s *userService) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (s *userService) ChangePassword(ctx context.Context, request ChangePasswordRequest) (UserResponse, error) {
-	if request.ID == "" || request.Password == "" {
+// ChangePassword canvia la contrasenya de l'usuari id, que ha de ser el que fa la
+// petició, després de comprovar la contrasenya actual.
+func (s *userService) ChangePassword(ctx context.Context, id string, request ChangePasswordRequest) (UserResponse, error) {
+	if request.OldPassword == "" || request.Password == "" {
 		return UserResponse{}, ErrInvalidRequest
 	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return UserResponse{}, ErrInvalidID
+	}
 
-	existingUser, err := s.repo.FindByID(ctx, uuid.MustParse(request.ID))
-	if err != nil && !errors.Is(err, ErrUserNotFound){
+	existingUser, err := s.repo.FindByID(ctx, parsedID)
+	if err != nil && !errors.Is(err, ErrUserNotFound) && !errors.Is(err, ErrInactiveUser){
 		return UserResponse{}, fmt.Errorf("something went wrong getting the user")
 	}
-	if errors.Is(err, ErrUserNotFound) {
+	if err != nil {
 		return UserResponse{}, err
 	}
-	if !existingUser.IsActive {
-		return UserResponse{}, ErrInactiveUser
+
+	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(request.OldPassword)); err != nil {
+		return UserResponse{}, ErrInvalidPassword
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	response, err := s.setPassword(ctx, parsedID, request.Password)
 	if err != nil {
 		return UserResponse{}, err
 	}
+	return MapUserToResponse(response), nil
+}
 
-	request.Password = string(hashedPassword)
+// ForgotPassword envia un enllaç per restablir la contrasenya. Com ResendVerification,
+// no diu si el correu existeix.
+func (s *userService) ForgotPassword(ctx context.Context, request ForgotPasswordRequest) error {
+	user, err := s.repo.FindByEmail(ctx, request.Email)
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrInactiveUser) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.tokenService.Invalidate(ctx, user.ID, usertokens.PurposePasswordReset); err != nil {
+		return err
+	}
 
-	response, err := s.repo.ChangePassword(ctx, request)
+	ttl := time.Duration(s.cfg.PasswordResetTTLMinutes) * time.Minute
+	token, err := s.tokenService.Issue(ctx, user.ID, usertokens.PurposePasswordReset, ttl)
 	if err != nil {
-		return UserResponse{}, err
+		return err
 	}
-	response.IsVerified = existingUser.IsVerified
-	response.CreatedAt = existingUser.CreatedAt
-	return mapUserToResponse(response), nil
+	link := s.cfg.AppURL + "/reset-password?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Restableix la contrasenya d'Orkestra",
+		Body: fmt.Sprintf("Hola %s,\n\nHem rebut una petició per restablir la teva contrasenya. Obre aquest enllaç per triar-ne una de nova:\n\n%s\n\nL'enllaç caduca d'aquí a %d minuts i només es pot fer servir una vegada. Si no has estat tu, ignora aquest correu.\n",
+			user.Name, link, s.cfg.PasswordResetTTLMinutes),
+	})
+}
+
+func (s *userService) ResetPassword(ctx context.Context, request ResetPasswordRequest) error {
+	userID, err := s.tokenService.Consume(ctx, usertokens.PurposePasswordReset, request.Token)
+	if errors.Is(err, usertokens.ErrInvalidToken) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.setPassword(ctx, userID, request.Password)
+	return err
+}
+
+// setPassword desa la nova contrasenya. El repositori actualitza password_changed_at, i
+// això invalida els JWT emesos abans; aquí s'anul·len els enllaços de restabliment pendents.
+func (s *userService) setPassword(ctx context.Context, id uuid.UUID, password string) (User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+	user, err := s.repo.ChangePassword(ctx, id, string(hashedPassword))
+	if err != nil {
+		return User{}, err
+	}
+	if err := s.tokenService.Invalidate(ctx, id, usertokens.PurposePasswordReset); err != nil {
+		return User{}, err
+	}
+	return user, nil
 }
 
 func (s *userService) VerifyUser(ctx context.Context, request VerifyUserRequest) (UserResponse, error) {
-	if request.ID == "" || request.ValidationString == "" {
+	if request.ValidationString == "" {
 		return UserResponse{}, ErrInvalidRequest
 	}
 
-	existingUser, err := s.repo.FindByID(ctx, uuid.MustParse(request.ID))
+	// El token és d'un sol ús: un cop consumit ja no es pot tornar a fer servir
+	userID, err := s.tokenService.Consume(ctx, usertokens.PurposeEmailVerification, request.ValidationString)
+	if errors.Is(err, usertokens.ErrInvalidToken) {
+		return UserResponse{}, ErrInvalidValidationString
+	}
+	if err != nil {
+		return UserResponse{}, err
+	}
+	if request.ID != "" && request.ID != userID.String() {
+		return UserResponse{}, ErrInvalidValidationString
+	}
+
+	existingUser, err := s.repo.FindByID(ctx, userID)
 	if err != nil && !errors.Is(err, ErrUserNotFound){
 		return UserResponse{}, fmt.Errorf("something went wrong getting the user")
 	}
@@ -210,20 +404,52 @@ func (s *userService) VerifyUser(ctx context.Context, request VerifyUserRequest)
 	if !existingUser.IsActive {
 		return UserResponse{}, ErrInactiveUser
 	}
-	//Validate the validation string
 
-
-	err = s.repo.VerifyUser(ctx, uuid.MustParse(request.ID))
+	err = s.repo.VerifyUser(ctx, userID)
 	if err != nil {
 		return UserResponse{}, err
 	}
 
-	response, err := s.repo.FindByID(ctx, uuid.MustParse(request.ID))
+	response, err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		return UserResponse{}, err
 	}
 
-	return mapUserToResponse(response), nil
+	return MapUserToResponse(response), nil
+}
+
+// ResendVerification envia un nou enllaç de verificació i anul·la els anteriors. No diu
+// si el correu existeix: si no hi ha cap usuari pendent de verificar no fa res.
+func (s *userService) ResendVerification(ctx context.Context, request ResendVerificationRequest) error {
+	user, err := s.repo.FindByEmail(ctx, request.Email)
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrInactiveUser) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.IsVerified {
+		return nil
+	}
+	if err := s.tokenService.Invalidate(ctx, user.ID, usertokens.PurposeEmailVerification); err != nil {
+		return err
+	}
+	return s.sendVerification(ctx, user)
+}
+
+func (s *userService) sendVerification(ctx context.Context, user User) error {
+	ttl := time.Duration(s.cfg.EmailVerificationTTLHours) * time.Hour
+	token, err := s.tokenService.Issue(ctx, user.ID, usertokens.PurposeEmailVerification, ttl)
+	if err != nil {
+		return err
+	}
+	link := s.cfg.AppURL + "/verify?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verifica el teu compte d'Orkestra",
+		Body: fmt.Sprintf("Hola %s,\n\nPer activar el teu compte obre aquest enllaç:\n\n%s\n\nL'enllaç caduca d'aquí a %d hores. Si no t'has registrat a Orkestra, ignora aquest correu.\n",
+			user.Name, link, s.cfg.EmailVerificationTTLHours),
+	})
 }
 
 func (s *userService) FindByUsername(ctx context.Context, username string) (UserResponse, error) {
@@ -239,7 +465,7 @@ func (s *userService) FindByUsername(ctx context.Context, username string) (User
 		return UserResponse{}, err
 	}
 
-	return mapUserToResponse(user), nil
+	return MapUserToResponse(user), nil
 }
 
 func (s *userService) FindByPhoneNumber(ctx context.Context, phoneNumber string) (UserResponse, error) {
@@ -255,7 +481,7 @@ func (s *userService) FindByPhoneNumber(ctx context.Context, phoneNumber string)
 		return UserResponse{}, err
 	}
 
-	return mapUserToResponse(user), nil
+	return MapUserToResponse(user), nil
 }
 
 func (s *userService) FindByID(ctx context.Context, id string) (UserResponse, error) {
@@ -272,7 +498,7 @@ func (s *userService) FindByID(ctx context.Context, id string) (UserResponse, er
 		return UserResponse{}, err
 	}
 
-	return mapUserToResponse(user), nil
+	return MapUserToResponse(user), nil
 }
 
 func (s *userService) FindAll(ctx context.Context) ([]UserResponse, error) {
@@ -283,7 +509,7 @@ func (s *userService) FindAll(ctx context.Context) ([]UserResponse, error) {
 
 	var userResponses []UserResponse
 	for _, user := range users {
-		userResponses = append(userResponses, mapUserToResponse(user))
+		userResponses = append(userResponses, MapUserToResponse(user))
 	}
 
 	return userResponses, nil
@@ -302,7 +528,7 @@ func (s *userService) FindByGroupId(ctx context.Context, groupID string) ([]User
 
 	var userResponses []UserResponse
 	for _, user := range users {
-		userResponses = append(userResponses, mapUserToResponse(user))
+		userResponses = append(userResponses, MapUserToResponse(user))
 	}
 
 	return userResponses, nil
@@ -0,0 +1,7 @@
+package usertokens
+
+import "errors"
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
@@ -0,0 +1,26 @@
+package usertokens
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Finalitats dels tokens d'un sol ús que s'envien als usuaris.
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+	PurposeTwoFactorLogin    = "two_factor_login"
+)
+
+// UserToken és un token d'un sol ús. Només se'n desa el hash: el valor en clar
+// només el rep l'usuari.
+type UserToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Purpose   string     `json:"purpose" db:"purpose"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,58 @@
+package usertokens
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type TokenRepository interface {
+	Create(ctx context.Context, token UserToken) error
+	Consume(ctx context.Context, purpose, tokenHash string) (uuid.UUID, error)
+	InvalidateByUserID(ctx context.Context, userID uuid.UUID, purpose string) error
+}
+
+type tokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+func (r *tokenRepository) Create(ctx context.Context, token UserToken) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())`,
+		token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt,
+	)
+	return err
+}
+
+// Consume marca el token com a usat i retorna l'usuari. La condició used_at IS NULL
+// dins del mateix UPDATE fa que dues peticions simultànies no el puguin fer servir totes dues.
+func (r *tokenRepository) Consume(ctx context.Context, purpose, tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`,
+		tokenHash, purpose,
+	).Scan(&userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+func (r *tokenRepository) InvalidateByUserID(ctx context.Context, userID uuid.UUID, purpose string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		userID, purpose,
+	)
+	return err
+}
@@ -0,0 +1,71 @@
+package usertokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenService interface {
+	Issue(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error)
+	Consume(ctx context.Context, purpose, token string) (uuid.UUID, error)
+	Invalidate(ctx context.Context, userID uuid.UUID, purpose string) error
+}
+
+type tokenService struct {
+	repo TokenRepository
+}
+
+func NewTokenService(repo TokenRepository) TokenService {
+	return &tokenService{repo: repo}
+}
+
+// Issue crea un token aleatori per a l'usuari i en retorna el valor en clar.
+func (s *tokenService) Issue(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	err := s.repo.Create(ctx, UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume valida el token i el gasta. Retorna ErrInvalidToken si no existeix, ha
+// caducat o ja s'ha fet servir.
+func (s *tokenService) Consume(ctx context.Context, purpose, token string) (uuid.UUID, error) {
+	if token == "" {
+		return uuid.Nil, ErrInvalidToken
+	}
+	userID, err := s.repo.Consume(ctx, purpose, HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return userID, err
+}
+
+// Invalidate gasta tots els tokens pendents de l'usuari per a aquesta finalitat.
+func (s *tokenService) Invalidate(ctx context.Context, userID uuid.UUID, purpose string) error {
+	return s.repo.InvalidateByUserID(ctx, userID, purpose)
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"io"
 	"log"
+	"strings"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
@@ -29,6 +30,10 @@ func (alm *ActionLogMiddleware) LogAction() gin.HandlerFunc {
 			userIDRaw = nil
 		}
 		actionType := c.Request.Method + " " + c.Request.URL.Path		
+		// Els tokens que viatgen a la ruta (invitacions) no s'han de desar
+		if token := c.Param("token"); token != "" {
+			actionType = strings.Replace(actionType, token, ":token", 1)
+		}
 		timezone := "" // Aquí després pots millorar-ho si vols agafar el timezone del client
 		performedAt := time.Now()
 
@@ -46,7 +51,12 @@ func (alm *ActionLogMiddleware) LogAction() gin.HandlerFunc {
 				
 				var jsonBody map[string]interface{}
 				if err := json.Unmarshal(bodyBytes, &jsonBody); err == nil {					
-					delete(jsonBody, "password")					
+					delete(jsonBody, "password")
+					delete(jsonBody, "old_password")
+					delete(jsonBody, "token")
+					delete(jsonBody, "validation_string")
+					delete(jsonBody, "challenge_token")
+					delete(jsonBody, "code")
 					modifiedBodyBytes, err := json.Marshal(jsonBody)
 					if err == nil {
 						metadata = string(modifiedBodyBytes)
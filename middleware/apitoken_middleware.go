@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"orkestra-api/internal/usertokens"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// APITokenPrefix distingeix els tokens personals dels JWT a la capçalera Authorization.
+const APITokenPrefix = "ork_"
+
+const apiTokenScopesKey = "api_token_scopes"
+
+// apiTokenTouchInterval evita escriure last_used_at a cada petició.
+const apiTokenTouchInterval = time.Minute
+
+// errAPITokenNotAllowed és la resposta a un token personal en una ruta que no declara cap permís.
+const errAPITokenNotAllowed = "this route cannot be used with an API token"
+
+// requireHandlerName és el nom del middleware que retorna Authorizer.Require. Totes les
+// rutes que en tenen un declaren el permís que necessiten.
+var requireHandlerName = handlerName(NewAuthorizer(nil).Require(""))
+
+// Authenticate accepta un token personal (Authorization: Bearer ork_...) o, si no n'hi ha,
+// passa la petició al middleware JWT. Amb un token personal deixa al context la mateixa
+// identitat que el JWT, i els scopes del token per a l'Authorizer. Un token personal
+// només pot entrar a les rutes que declaren un permís amb Require: les que no en tenen
+// (el compte, la contrasenya, el 2FA, les sessions...) són només per a sessions d'usuari.
+func Authenticate(db *sql.DB, jwtMiddleware *jwt.GinJWTMiddleware) gin.HandlerFunc {
+	jwtHandler := jwtMiddleware.MiddlewareFunc()
+	return func(c *gin.Context) {
+		token := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), jwtMiddleware.TokenHeadName))
+		if !strings.HasPrefix(token, APITokenPrefix) {
+			jwtHandler(c)
+			return
+		}
+		if !declaresPermission(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errAPITokenNotAllowed})
+			return
+		}
+
+		var tokenID, userID uuid.UUID
+		var scopes []string
+		var lastUsedAt sql.NullTime
+		err := db.QueryRowContext(c.Request.Context(), `
+			SELECT t.id, t.user_id, t.scopes, t.last_used_at
+			FROM api_tokens t
+				INNER JOIN users u ON u.id = t.user_id
+			WHERE t.token_hash = $1 AND t.revoked_at IS NULL
+				AND (t.expires_at IS NULL OR t.expires_at > NOW())
+				AND u.is_active`, usertokens.HashToken(token)).Scan(&tokenID, &userID, pq.Array(&scopes), &lastUsedAt)
+		if err == sql.ErrNoRows {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "invalid, expired or revoked API token"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking API token: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error checking API token"})
+			return
+		}
+
+		if !lastUsedAt.Valid || time.Since(lastUsedAt.Time) > apiTokenTouchInterval {
+			if _, err := db.ExecContext(c.Request.Context(), `
+				UPDATE api_tokens
+				SET last_used_at = NOW()
+				WHERE id = $1`, tokenID); err != nil {
+				log.Printf("Error updating API token %s: %v", tokenID, err)
+			}
+		}
+
+		scopeSet := make(map[string]struct{}, len(scopes))
+		for _, scope := range scopes {
+			scopeSet[scope] = struct{}{}
+		}
+		c.Set("JWT_PAYLOAD", jwt.MapClaims{"id": userID.String()})
+		c.Set(jwtMiddleware.IdentityKey, userID.String())
+		c.Set(apiTokenScopesKey, scopeSet)
+		c.Next()
+	}
+}
+
+// IsAPITokenRequest indica si la petició s'ha autenticat amb un token personal.
+func IsAPITokenRequest(c *gin.Context) bool {
+	_, exists := c.Get(apiTokenScopesKey)
+	return exists
+}
+
+// RejectAPITokens respon 403 als tokens personals. Es fa servir a les rutes que gestionen
+// la identitat o les credencials de l'usuari, a més del rebuig general d'Authenticate.
+func RejectAPITokens(c *gin.Context) {
+	if IsAPITokenRequest(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errAPITokenNotAllowed})
+		return
+	}
+	c.Next()
+}
+
+// declaresPermission indica si la cadena de handlers de la ruta inclou un Require.
+func declaresPermission(c *gin.Context) bool {
+	for _, name := range c.HandlerNames() {
+		if name == requireHandlerName {
+			return true
+		}
+	}
+	return false
+}
+
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
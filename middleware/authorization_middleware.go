@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const permissionsKey = "permissions"
+
+// UserPermissions són els permisos del perfil de l'usuari que fa la petició.
+// Els perfils d'administrador els tenen tots. Amb un token personal, Scopes limita
+// els permisos als que té el token.
+type UserPermissions struct {
+	Admin       bool
+	Permissions map[string]struct{}
+	Scopes      map[string]struct{}
+}
+
+func (p UserPermissions) Has(permission string) bool {
+	if p.Scopes != nil {
+		if _, ok := p.Scopes[permission]; !ok {
+			return false
+		}
+	}
+	if p.Admin {
+		return true
+	}
+	_, ok := p.Permissions[permission]
+	return ok
+}
+
+// Authorizer comprova els permisos del perfil de l'usuari a cada ruta protegida.
+// Cada paquet declara el permís de les seves rutes a RegisterRoutes amb Require, i
+// així Declared coneix tots els permisos que existeixen.
+type Authorizer struct {
+	db       *sql.DB
+	mu       sync.RWMutex
+	declared map[string]struct{}
+}
+
+func NewAuthorizer(db *sql.DB) *Authorizer {
+	return &Authorizer{db: db, declared: make(map[string]struct{})}
+}
+
+// Declare afegeix permisos que no protegeixen cap ruta sencera, sinó una part de la
+// resposta (per exemple operators:read_cost).
+func (a *Authorizer) Declare(permissions ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, permission := range permissions {
+		a.declared[permission] = struct{}{}
+	}
+}
+
+// Declared retorna tots els permisos declarats per les rutes, ordenats.
+func (a *Authorizer) Declared() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	permissions := make([]string, 0, len(a.declared))
+	for permission := range a.declared {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// IsDeclared indica si el permís existeix.
+func (a *Authorizer) IsDeclared(permission string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	_, ok := a.declared[permission]
+	return ok
+}
+
+// Require retorna un middleware que respon 403 si el perfil de l'usuari no té el permís.
+// S'ha de fer servir darrere del middleware JWT.
+func (a *Authorizer) Require(permission string) gin.HandlerFunc {
+	a.Declare(permission)
+	return func(c *gin.Context) {
+		permissions, err := a.load(c)
+		if err != nil {
+			log.Printf("Error loading permissions: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error loading permissions"})
+			return
+		}
+		if !permissions.Has(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing permission " + permission})
+			return
+		}
+		c.Next()
+	}
+}
+
+// load llegeix els permisos de l'usuari un sol cop per petició i els deixa al context.
+func (a *Authorizer) load(c *gin.Context) (UserPermissions, error) {
+	if cached, exists := c.Get(permissionsKey); exists {
+		return cached.(UserPermissions), nil
+	}
+	permissions := UserPermissions{Permissions: make(map[string]struct{})}
+	if scopes, exists := c.Get(apiTokenScopesKey); exists {
+		permissions.Scopes = scopes.(map[string]struct{})
+	}
+
+	userID, exists := c.Get("id")
+	if !exists {
+		return permissions, nil
+	}
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		return permissions, nil
+	}
+
+	// Un usuari sense perfil no té cap permís
+	rows, err := a.db.QueryContext(c.Request.Context(), `
+		SELECT p.is_admin, pp.permission
+		FROM users u
+			INNER JOIN profiles p ON p.id = u.profile_id
+			LEFT JOIN profile_permissions pp ON pp.profile_id = p.id
+		WHERE u.id = $1`, userUUID)
+	if err != nil {
+		return permissions, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var permission sql.NullString
+		if err := rows.Scan(&permissions.Admin, &permission); err != nil {
+			return permissions, err
+		}
+		if permission.Valid {
+			permissions.Permissions[permission.String] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return permissions, err
+	}
+
+	c.Set(permissionsKey, permissions)
+	return permissions, nil
+}
+
+// HasPermission indica si l'usuari de la petició té el permís. Només té en compte els
+// permisos que ha carregat un Require anterior a la mateixa ruta.
+func HasPermission(c *gin.Context, permission string) bool {
+	cached, exists := c.Get(permissionsKey)
+	if !exists {
+		return false
+	}
+	return cached.(UserPermissions).Has(permission)
+}
+
+// IsAdmin indica si l'usuari de la petició té un perfil d'administrador. Com HasPermission,
+// només funciona darrere d'un Require.
+func IsAdmin(c *gin.Context) bool {
+	cached, exists := c.Get(permissionsKey)
+	if !exists {
+		return false
+	}
+	return cached.(UserPermissions).Admin
+}
@@ -9,6 +9,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// TokenClaims són les dades amb què es genera el token en iniciar sessió.
+type TokenClaims struct {
+    UserID    string
+    SessionID string
+}
+
 func SetupJWT(cfg *config.Config) (*jwt.GinJWTMiddleware, error) {
     return jwt.New(&jwt.GinJWTMiddleware{
         Realm:       "orkestra-api",
@@ -17,9 +23,20 @@ func SetupJWT(cfg *config.Config) (*jwt.GinJWTMiddleware, error) {
         MaxRefresh:  time.Hour * 24,
         IdentityKey: "id",
         PayloadFunc: func(data interface{}) jwt.MapClaims {
+            // jti identifica la sessió a la taula sessions
+            if v, ok := data.(TokenClaims); ok {
+                return jwt.MapClaims{
+                    "id": v.UserID,
+                    "jti": v.SessionID,
+                    "auth_time": time.Now().Unix(),
+                }
+            }
             if v, ok := data.(string); ok {
+                // auth_time es manté en refrescar el token i permet invalidar-lo
+                // quan l'usuari canvia la contrasenya
                 return jwt.MapClaims{
                     "id": v,
+                    "auth_time": time.Now().Unix(),
                 }
             }
             return jwt.MapClaims{}
@@ -39,8 +56,7 @@ func SetupJWT(cfg *config.Config) (*jwt.GinJWTMiddleware, error) {
         },
         // Verificar si l'usuari té accés a una ruta específica
         Authorizator: func(data interface{}, c *gin.Context) bool {
-            // Aquí pots implementar una lògica més complexa de verificació de permisos
-            // Per exemple, verificar el rol de l'usuari per a rutes específiques
+            // Els permisos de cada ruta els comprova Authorizer segons el perfil de l'usuari
             return data != nil
         },
         Unauthorized: func(c *gin.Context, code int, message string) {
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// RejectStaleTokens respon 401 als tokens emesos abans de l'últim canvi de contrasenya
+// de l'usuari. S'ha de fer servir darrere del middleware JWT. Els tokens personals no
+// depenen de la contrasenya.
+func RejectStaleTokens(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPITokenRequest(c) {
+			c.Next()
+			return
+		}
+		claims := jwt.ExtractClaims(c)
+		userID, _ := claims["id"].(string)
+		// Els tokens anteriors a aquest control no porten auth_time i es consideren caducats
+		authTime, _ := claims["auth_time"].(float64)
+
+		var passwordChangedAt sql.NullTime
+		err := db.QueryRowContext(c.Request.Context(), `
+			SELECT password_changed_at
+			FROM users
+			WHERE id = $1`, userID).Scan(&passwordChangedAt)
+		if err == sql.ErrNoRows {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "user not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking token age: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error checking token"})
+			return
+		}
+
+		// auth_time té resolució de segons
+		if passwordChangedAt.Valid && passwordChangedAt.Time.Truncate(time.Second).Unix() > int64(authTime) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "token issued before the last password change"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sessionTouchInterval evita escriure last_seen_at a cada petició.
+const sessionTouchInterval = time.Minute
+
+// ValidateSession respon 401 si la sessió del token (claim jti) s'ha revocat, ha caducat
+// o l'usuari està desactivat. S'ha de fer servir darrere del middleware JWT. Els tokens
+// personals no tenen sessió: Authenticate ja n'ha comprovat l'estat.
+func ValidateSession(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPITokenRequest(c) {
+			c.Next()
+			return
+		}
+		claims := jwt.ExtractClaims(c)
+		userID, _ := claims["id"].(string)
+		sessionID, _ := claims["jti"].(string)
+		if _, err := uuid.Parse(sessionID); err != nil {
+			abortSession(c, "token without session")
+			return
+		}
+		if _, err := uuid.Parse(userID); err != nil {
+			abortSession(c, "invalid token")
+			return
+		}
+
+		var lastSeenAt time.Time
+		err := db.QueryRowContext(c.Request.Context(), `
+			SELECT s.last_seen_at
+			FROM sessions s
+				INNER JOIN users u ON u.id = s.user_id
+			WHERE s.id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL AND s.expires_at > NOW()
+				AND u.is_active`, sessionID, userID).Scan(&lastSeenAt)
+		if err == sql.ErrNoRows {
+			abortSession(c, "session expired or revoked")
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking session: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error checking session"})
+			return
+		}
+
+		if time.Since(lastSeenAt) > sessionTouchInterval {
+			if _, err := db.ExecContext(c.Request.Context(), `
+				UPDATE sessions
+				SET last_seen_at = NOW()
+				WHERE id = $1`, sessionID); err != nil {
+				log.Printf("Error updating session %s: %v", sessionID, err)
+			}
+		}
+		c.Next()
+	}
+}
+
+func abortSession(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": message})
+}
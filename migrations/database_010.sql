@@ -0,0 +1,21 @@
+CREATE TABLE llm_conversations (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_llm_conversations_user_id ON llm_conversations(user_id);
+
+CREATE TABLE llm_conversation_turns (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES llm_conversations(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    sql_query TEXT,
+    result_summary TEXT,
+    answer TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_llm_conversation_turns_conversation_id ON llm_conversation_turns(conversation_id, created_at);
@@ -0,0 +1,23 @@
+CREATE TABLE llm_queries (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    conversation_id UUID REFERENCES llm_conversations(id) ON DELETE SET NULL,
+    question TEXT NOT NULL,
+    mode VARCHAR(20) NOT NULL DEFAULT '',
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    sql_query TEXT,
+    row_count INT NOT NULL DEFAULT 0,
+    classify_ms INT NOT NULL DEFAULT 0,
+    sql_generation_ms INT NOT NULL DEFAULT 0,
+    execution_ms INT NOT NULL DEFAULT 0,
+    answer_ms INT NOT NULL DEFAULT 0,
+    total_ms INT NOT NULL DEFAULT 0,
+    prompt_tokens INT NOT NULL DEFAULT 0,
+    completion_tokens INT NOT NULL DEFAULT 0,
+    llm_calls INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_llm_queries_user_id_created_at ON llm_queries(user_id, created_at);
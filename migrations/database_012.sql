@@ -0,0 +1,14 @@
+CREATE TABLE llm_query_cache (
+    cache_key VARCHAR(64) PRIMARY KEY,
+    question TEXT NOT NULL,
+    scope VARCHAR(100) NOT NULL,
+    schema_version VARCHAR(32) NOT NULL,
+    sql_query TEXT NOT NULL,
+    answer TEXT,
+    answer_expires_at TIMESTAMP,
+    hits INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_hit_at TIMESTAMP
+);
+
+ALTER TABLE llm_queries ADD COLUMN cache_status VARCHAR(20);
@@ -0,0 +1,32 @@
+ALTER TABLE profiles ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE profile_permissions (
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (profile_id, permission)
+);
+
+CREATE INDEX idx_profile_permissions_profile_id ON profile_permissions(profile_id);
+
+-- Els perfils amb is_admin tenen tots els permisos. Cap perfil ho és per defecte: el
+-- servidor marca a l'arrencada el perfil de ADMIN_PROFILE_ID i no arrenca si no n'hi ha cap.
+-- La resta es gestiona amb l'API de perfils
+
+-- Perfils dels usuaris de client: només poden consultar els seus projectes i tasques
+INSERT INTO profile_permissions (profile_id, permission)
+SELECT p.id, v.permission
+FROM profiles p
+    CROSS JOIN (VALUES ('menus:read'), ('projects:read'), ('tasks:read'), ('llm:query')) AS v(permission)
+WHERE NOT p.is_admin
+    AND EXISTS (SELECT 1 FROM users u WHERE u.profile_id = p.id AND u.is_customer);
+
+-- La resta de perfils poden consultar-ho tot i gestionar reunions i tasques, però no
+-- usuaris, menús, perfils ni el cost dels operaris
+INSERT INTO profile_permissions (profile_id, permission)
+SELECT p.id, v.permission
+FROM profiles p
+    CROSS JOIN (VALUES ('users:read'), ('groups:read'), ('meetings:read'), ('meetings:write'), ('search:read'),
+        ('customers:read'), ('projects:read'), ('tasks:read'), ('tasks:write'), ('costitems:read'),
+        ('menus:read'), ('operators:read'), ('llm:query')) AS v(permission)
+WHERE NOT p.is_admin
+    AND NOT EXISTS (SELECT 1 FROM users u WHERE u.profile_id = p.id AND u.is_customer);
@@ -0,0 +1,17 @@
+-- Cada grup existent passa a tenir com a administrador el seu membre més antic
+UPDATE group_members SET is_admin = FALSE WHERE is_admin IS NULL;
+UPDATE group_members gm
+SET is_admin = TRUE
+WHERE gm.ID IN (
+    SELECT DISTINCT ON (group_id) ID
+    FROM group_members
+    WHERE group_id IS NOT NULL AND user_id IS NOT NULL
+    ORDER BY group_id, joined_at NULLS LAST, ID
+)
+AND NOT EXISTS (
+    SELECT 1 FROM group_members a
+    WHERE a.group_id = gm.group_id AND a.is_admin
+);
+ALTER TABLE group_members ALTER COLUMN is_admin SET DEFAULT FALSE;
+ALTER TABLE group_members ALTER COLUMN is_admin SET NOT NULL;
+CREATE INDEX idx_group_member_group_user ON group_members(group_id, user_id);
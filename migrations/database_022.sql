@@ -0,0 +1,27 @@
+CREATE TABLE meeting_series (
+    id UUID PRIMARY KEY,
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE RESTRICT,
+    title VARCHAR(250) NOT NULL,
+    description TEXT,
+    start_time TIMESTAMPTZ NOT NULL,
+    rrule TEXT NOT NULL,
+    participant_ids UUID[] NOT NULL DEFAULT '{}',
+    topic_titles TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    materialized_until TIMESTAMPTZ
+);
+
+CREATE INDEX idx_meeting_series_group ON meeting_series(group_id);
+
+CREATE TABLE meeting_series_exceptions (
+    series_id UUID NOT NULL REFERENCES meeting_series(id) ON DELETE CASCADE,
+    occurrence_start TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (series_id, occurrence_start)
+);
+
+ALTER TABLE meetings
+ADD COLUMN series_id UUID REFERENCES meeting_series(id) ON DELETE SET NULL,
+ADD COLUMN occurrence_start TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX idx_meetings_series_occurrence ON meetings(series_id, occurrence_start) WHERE series_id IS NOT NULL;
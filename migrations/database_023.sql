@@ -0,0 +1,12 @@
+ALTER TABLE tasks
+ADD COLUMN agreement_id UUID REFERENCES meeting_topic_agreements(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_tasks_agreement_id ON tasks(agreement_id) WHERE agreement_id IS NOT NULL;
+
+CREATE TABLE meeting_carried_tasks (
+    meeting_id UUID NOT NULL REFERENCES meetings(id) ON DELETE CASCADE,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    carried_from UUID REFERENCES meetings(id) ON DELETE SET NULL,
+    carried_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (meeting_id, task_id)
+);
@@ -0,0 +1,3 @@
+-- IPs des d'on s'ha fallat el login de cada nom d'usuari, per poder-les desbloquejar juntes
+ALTER TABLE login_throttles
+ADD COLUMN ips TEXT[] NOT NULL DEFAULT '{}';
@@ -1,21 +1,32 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"orkestra-api/config"
+	"orkestra-api/internal/apitokens"
 	"orkestra-api/internal/auth"
+	"orkestra-api/internal/calendarfeeds"
 	"orkestra-api/internal/costitems"
 	"orkestra-api/internal/customers"
 	"orkestra-api/internal/groups"
 	"orkestra-api/internal/health"
+	"orkestra-api/internal/invitations"
 	"orkestra-api/internal/llm"
+	"orkestra-api/internal/mailer"
+	"orkestra-api/internal/me"
 	"orkestra-api/internal/meetings"
 	"orkestra-api/internal/menus"
 	"orkestra-api/internal/operators"
+	"orkestra-api/internal/profiles"
 	"orkestra-api/internal/projects"
 	"orkestra-api/internal/searches"
+	"orkestra-api/internal/sessions"
 	"orkestra-api/internal/tasks"
+	"orkestra-api/internal/twofactor"
 	"orkestra-api/internal/users"
+	"orkestra-api/internal/usertokens"
 	"orkestra-api/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -36,6 +47,12 @@ func NewServer(cfg *config.Config, db *sql.DB) *Server {
 }
 
 func (s *Server) Setup() error {
+	// Només es fa cas de X-Forwarded-For si ve d'un proxy de confiança: sense TRUSTED_PROXIES,
+	// la IP del client és la de la connexió i el límit de logins per IP no es pot falsejar
+	if err := s.router.SetTrustedProxies(s.cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
 	// CORS middleware
 	s.router.Use(middleware.SetupCORS())
 	
@@ -48,6 +65,9 @@ func (s *Server) Setup() error {
 
 	// Action log middleware
 	actionLogMiddleware := middleware.NewActionLogMiddleware(s.db)
+
+	// Permisos per perfil
+	authorizer := middleware.NewAuthorizer(s.db)
 	
 	// Inicialitzar repositoris
 	userRepo := users.NewUserRepository(s.db)
@@ -60,21 +80,52 @@ func (s *Server) Setup() error {
 	costItemRepo := costitems.NewCostItemRepository(s.db)
 	menuRepo := menus.NewMenuRepository(s.db)
 	operatorRepo := operators.NewOperatorRepository(s.db)
+	llmRepo := llm.NewRepository(s.db)
+	profileRepo := profiles.NewProfileRepository(s.db)
+	tokenRepo := usertokens.NewTokenRepository(s.db)
+	sessionRepo := sessions.NewSessionRepository(s.db)
+	twoFactorRepo := twofactor.NewTwoFactorRepository(s.db)
+	apiTokenRepo := apitokens.NewAPITokenRepository(s.db)
+	invitationRepo := invitations.NewInvitationRepository(s.db)
+	calendarFeedRepo := calendarfeeds.NewCalendarFeedRepository(s.db)
+
 
+	// Correu
+	mail := mailer.NewMailer(*s.cfg)
 
 	// Inicialitzar serveis
-	userService := users.NewUserService(userRepo)
-	authService := auth.NewAuthService(userRepo, authMiddleware)	
+	tokenService := usertokens.NewTokenService(tokenRepo)
+	userService := users.NewUserService(userRepo, tokenService, mail, *s.cfg)
+	sessionService := sessions.NewSessionService(sessionRepo, authMiddleware.MaxRefresh)
+	twoFactorService := twofactor.NewTwoFactorService(twoFactorRepo)
+	authService := auth.NewAuthService(userRepo, auth.NewThrottleRepository(s.db), authMiddleware, sessionService, tokenService, twoFactorService, actionLogMiddleware, *s.cfg)	
 	groupService := groups.NewGroupService(groupRepo)
-	meetingService := meetings.NewMeetingService(meetingRepo)
 	searchService := searches.NewSearchService(searchRepo)
 	customerService := customers.NewCustomerService(customerRepo)
 	projectService := projects.NewProjectService(projectRepo, customerService)
 	taskService := tasks.NewTaskService(taskRepo, userService, projectService)
+	meetingService := meetings.NewMeetingService(meetingRepo, groupService, taskService)
 	costItemService := costitems.NewCostItemService(costItemRepo, projectService)
 	menuService := menus.NewMenuService(menuRepo)
 	operatorService := operators.NewOperatorService(operatorRepo)
-	llmService := llm.NewService(s.db, *s.cfg)
+	profileService := profiles.NewProfileService(profileRepo, menuService, authorizer)
+	// El perfil d'administració es tria per configuració, no pel nom del perfil. Sense cap
+	// perfil d'administració ningú podria gestionar els permisos, així que no s'arrenca
+	if err := profileService.EnsureAdmin(context.Background(), s.cfg.AdminProfileID); err != nil {
+		return fmt.Errorf("error checking admin profile: %w", err)
+	}
+	meService := me.NewMeService(userService, profileService, groupService, customerService)
+	apiTokenService := apitokens.NewAPITokenService(apiTokenRepo, authorizer)
+	invitationService := invitations.NewInvitationService(invitationRepo, userRepo, profileService, groupService, customerService, mail, *s.cfg)
+	calendarFeedService := calendarfeeds.NewCalendarFeedService(calendarFeedRepo, meetingService, taskService, projectService, *s.cfg)
+	llmProvider, err := llm.NewProvider(llm.ProviderConfigFromEnv(*s.cfg))
+	if err != nil {
+		return err
+	}
+	llmService := llm.NewService(s.db, *s.cfg, llmProvider, llmRepo, customerService)
+	if err := llmService.LoadSchema(context.Background()); err != nil {
+		return err
+	}
 	
 
 
@@ -82,7 +133,11 @@ func (s *Server) Setup() error {
 	userHandler := users.NewUserHandler(userService)
 	authHandler := auth.NewAuthHandler(authService, authMiddleware)
 	groupHandler := groups.NewGroupHandler(groupService)
-	meetingHandler := meetings.NewMeetingHandler(meetingService)
+	minutesRenderer, err := meetings.NewMinutesRenderer(s.cfg.MinutesTemplateDir)
+	if err != nil {
+		return err
+	}
+	meetingHandler := meetings.NewMeetingHandler(meetingService, minutesRenderer)
 	searchHandler := searches.NewSearchHandler(searchService)
 	customerHandler := customers.NewCustomerHandler(customerService)
 	projectHandler := projects.NewProjectHandler(projectService)
@@ -91,6 +146,13 @@ func (s *Server) Setup() error {
 	menuHandler := menus.NewMenuHandler(menuService)
 	operatorHandler := operators.NewOperatorHandler(operatorService)
 	llmHandler := llm.NewHandler(llmService)
+	profileHandler := profiles.NewProfileHandler(profileService)
+	sessionHandler := sessions.NewSessionHandler(sessionService)
+	twoFactorHandler := twofactor.NewTwoFactorHandler(twoFactorService)
+	meHandler := me.NewMeHandler(meService)
+	apiTokenHandler := apitokens.NewAPITokenHandler(apiTokenService)
+	invitationHandler := invitations.NewInvitationHandler(invitationService)
+	calendarFeedHandler := calendarfeeds.NewCalendarFeedHandler(calendarFeedService)
 
 	
 	// Configurar les rutes públiques (sense autenticació)
@@ -98,27 +160,43 @@ func (s *Server) Setup() error {
 	public.Use(actionLogMiddleware.LogAction())
 	public.GET("/health", health.CheckHealth)
 	users.RegisterPublicRoutes(public, userHandler)
+	invitations.RegisterPublicRoutes(public, invitationHandler)
 	auth.RegisterRoutes(public, authHandler, authMiddleware)
 
+	// Subscripcions de calendari: s'autentiquen amb el token de l'URL. No passen per
+	// l'action log perquè els clients de calendari les consulten periòdicament.
+	feeds := s.router.Group("/feeds")
+	calendarfeeds.RegisterPublicRoutes(feeds, calendarFeedHandler)
+
 
 	// Configurar les rutes protegides (amb autenticació JWT)
 	protected := s.router.Group("/api")
-	protected.Use(authMiddleware.MiddlewareFunc())
+	protected.Use(middleware.Authenticate(s.db, authMiddleware))
+	protected.Use(middleware.RejectStaleTokens(s.db))
+	protected.Use(middleware.ValidateSession(s.db))
 	protected.Use(actionLogMiddleware.LogAction())
 	
 
 	// Registrar les rutes protegides
-	users.RegisterRoutes(protected, userHandler)
-	groups.RegisterRoutes(protected, groupHandler)
-	meetings.RegisterRoutes(protected, meetingHandler)
-	searches.RegisterRoutes(protected, searchHandler)
-	customers.RegisterRoutes(protected, customerHandler)
-	projects.RegisterRoutes(protected, projectHandler)
-	tasks.RegisterRoutes(protected, taskHandler)
-	costitems.RegisterRoutes(protected, costItemHandler)
-	menus.RegisterRoutes(protected, menuHandler)
-	operators.RegisterRoutes(protected, operatorHandler)
-	llm.RegisterRoutes(protected, llmHandler) // LLM routes are registered at the root level
+	users.RegisterRoutes(protected, userHandler, authorizer)
+	auth.RegisterAdminRoutes(protected, authHandler, authorizer)
+	groups.RegisterRoutes(protected, groupHandler, authorizer)
+	meetings.RegisterRoutes(protected, meetingHandler, authorizer)
+	searches.RegisterRoutes(protected, searchHandler, authorizer)
+	customers.RegisterRoutes(protected, customerHandler, authorizer)
+	projects.RegisterRoutes(protected, projectHandler, authorizer)
+	tasks.RegisterRoutes(protected, taskHandler, authorizer)
+	costitems.RegisterRoutes(protected, costItemHandler, authorizer)
+	menus.RegisterRoutes(protected, menuHandler, authorizer)
+	operators.RegisterRoutes(protected, operatorHandler, authorizer)
+	profiles.RegisterRoutes(protected, profileHandler, authorizer)
+	sessions.RegisterRoutes(protected, sessionHandler, authorizer)
+	twofactor.RegisterRoutes(protected, twoFactorHandler)
+	me.RegisterRoutes(protected, meHandler)
+	apitokens.RegisterRoutes(protected, apiTokenHandler)
+	invitations.RegisterRoutes(protected, invitationHandler, authorizer)
+	calendarfeeds.RegisterRoutes(protected, calendarFeedHandler)
+	llm.RegisterRoutes(protected, llmHandler, authorizer) // LLM routes are registered at the root level
 	
 	return nil
 }